@@ -0,0 +1,41 @@
+package environment
+
+import "context"
+
+// CheckResult for a single reference, see Check.
+type CheckResult struct {
+	// Name of the environment variable.
+	Name string
+
+	// Reference without any options that are handled by aws-env, e.g. sm://<path>#<key>.
+	Reference string
+
+	// Err is nil if the reference was resolved successfully.
+	Err *ReferenceError
+}
+
+// Check that every reference in env (using the same format as Resolve) can be resolved, without
+// returning the secret values or writing secrets to files. References are resolved in the same
+// way as for Resolve, which means that the check covers e.g. permissions, decryption and missing
+// keys in multi-value secrets, and that it requires the same permissions as Resolve. The results
+// have the same order as the references in env (there can be several results for the same
+// variable if references are embedded in its value), and the returned error is only set if env
+// itself is malformed.
+func (m *Manager) Check(ctx context.Context, env []string) ([]*CheckResult, error) {
+	refs, _, err := m.parseEnvironment(env)
+	if err != nil {
+		return nil, err
+	}
+	// Failures are recorded on each reference.
+	_ = m.resolve(context.WithValue(ctx, dryRunKey{}, true), refs)
+
+	results := make([]*CheckResult, len(refs))
+	for i, ref := range refs {
+		reference := ref.scheme + schemeDelimiter + ref.path
+		if ref.multiValue {
+			reference += mvsDelimiter + ref.key
+		}
+		results[i] = &CheckResult{Name: ref.name, Reference: reference, Err: ref.failed}
+	}
+	return results, nil
+}
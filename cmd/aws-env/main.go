@@ -1,11 +1,17 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"strings"
 	"syscall"
+	"text/tabwriter"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/jessevdk/go-flags"
@@ -17,11 +23,119 @@ var command rootCommand
 var version string
 
 type rootCommand struct {
-	Version func()      `short:"v" long:"version" description:"Print the version and exit."`
-	Exec    execCommand `command:"exec" description:"Execute a command."`
+	Version func()         `short:"v" long:"version" description:"Print the version and exit."`
+	Exec    execCommand    `command:"exec" description:"Execute a command."`
+	Resolve resolveCommand `command:"resolve" description:"Print the resolved environment."`
+	Check   checkCommand   `command:"check" description:"Check that all references can be resolved, without printing any secrets."`
+	Encrypt encryptCommand `command:"encrypt" description:"Encrypt a secret from stdin and print a kms:// reference."`
+	Decrypt decryptCommand `command:"decrypt" description:"Decrypt a kms:// reference and print the secret."`
+	Put     putCommand     `command:"put" description:"Write a secret from stdin to an sm:// or ssm:// reference."`
 }
 
-type execCommand struct{}
+// awsOptions are shared by all commands that make requests to AWS.
+type awsOptions struct {
+	Timeout        time.Duration `long:"timeout" description:"Timeout for all requests to AWS (e.g. 30s). Disabled by default."`
+	RequestTimeout time.Duration `long:"request-timeout" description:"Timeout for each request to AWS (e.g. 5s). Disabled by default."`
+}
+
+// manager returns a Manager that is configured using the options.
+func (o *awsOptions) manager(opts ...environment.Option) (*environment.Manager, error) {
+	sess, err := session.NewSession()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create new aws session: %s", err)
+	}
+
+	env, err := environment.New(sess, append(opts, environment.WithRequestTimeout(o.RequestTimeout))...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize aws-env: %s", err)
+	}
+	return env, nil
+}
+
+// context returns a context with the configured timeout (if any).
+func (o *awsOptions) context() (context.Context, context.CancelFunc) {
+	if o.Timeout > 0 {
+		return context.WithTimeout(context.Background(), o.Timeout)
+	}
+	return context.WithCancel(context.Background())
+}
+
+// resolveOptions are shared by the commands that resolve the environment.
+type resolveOptions struct {
+	awsOptions
+	Concurrency int      `long:"concurrency" default:"10" description:"Maximum number of secrets to resolve in parallel."`
+	FileDir     string   `long:"file-dir" description:"Directory for secrets that are written to files (defaults to the system temp directory)."`
+	FileSuffix  string   `long:"file-suffix" description:"Write secrets referenced by variables with this suffix (e.g. _FILE) to files. Disabled by default."`
+	EnvFiles    []string `long:"env-file" description:"Load variables from a dotenv file, which take precedence over the inherited environment (can be repeated, later files take precedence)."`
+	NoInherit   bool     `long:"no-inherit" description:"Only use the variables from the env files, instead of merging them into the inherited environment."`
+}
+
+// manager returns a Manager that is configured using the options.
+func (o *resolveOptions) manager() (*environment.Manager, error) {
+	return o.awsOptions.manager(
+		environment.WithConcurrency(o.Concurrency),
+		environment.WithFileDirectory(o.FileDir),
+		environment.WithFileSuffix(o.FileSuffix),
+	)
+}
+
+// environ returns the inherited environment merged with the variables from the env files,
+// or only the variables from the env files if the environment should not be inherited.
+func (o *resolveOptions) environ() ([]string, error) {
+	files, err := readEnvFiles(o.EnvFiles)
+	if err != nil {
+		return nil, err
+	}
+	if o.NoInherit {
+		return merge(nil, files), nil
+	}
+	return merge(os.Environ(), files), nil
+}
+
+// merge returns env with the variables from files, which take precedence over env (and later
+// variables take precedence over earlier ones). Overridden variables keep their position, and
+// new variables are appended in order.
+func merge(env, files []string) []string {
+	var (
+		vars  = make([]string, 0, len(env)+len(files))
+		index = make(map[string]int, len(env)+len(files))
+	)
+	for _, src := range [][]string{env, files} {
+		for _, v := range src {
+			name, _, _ := strings.Cut(v, "=")
+			if i, ok := index[name]; ok {
+				vars[i] = v
+				continue
+			}
+			index[name] = len(vars)
+			vars = append(vars, v)
+		}
+	}
+	return vars
+}
+
+// resolve the environment. Files that secrets were written to are removed if any
+// of the secrets cannot be resolved, and are otherwise left in place.
+func (o *resolveOptions) resolve(vars []string) (*environment.Manager, []string, error) {
+	env, err := o.manager()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ctx, cancel := o.context()
+	defer cancel()
+
+	resolved, err := env.ResolveContext(ctx, vars)
+	if err != nil {
+		env.RemoveFiles()
+		return nil, nil, fmt.Errorf("failed to populate environment: %w", err)
+	}
+	return env, resolved, nil
+}
+
+type execCommand struct {
+	resolveOptions
+}
 
 // Execute the exec subcommand.
 func (c *execCommand) Execute(args []string) error {
@@ -33,25 +147,412 @@ func (c *execCommand) Execute(args []string) error {
 		return fmt.Errorf("failed to validate command: %s", err)
 	}
 
-	sess, err := session.NewSession()
+	vars, err := c.environ()
 	if err != nil {
-		return fmt.Errorf("failed to create new aws session: %s", err)
+		return err
 	}
 
-	env, err := environment.New(sess)
+	// Files are left in place once the command has been executed, since
+	// the command replaces this process and needs to read them.
+	env, resolved, err := c.resolve(vars)
 	if err != nil {
-		return fmt.Errorf("failed to initialize aws-env: %s", err)
-	}
-	if err := env.Populate(); err != nil {
-		return fmt.Errorf("failed to populate environment: %s", err)
+		return err
 	}
 
-	if err := syscall.Exec(path, args, os.Environ()); err != nil {
+	if err := syscall.Exec(path, args, resolved); err != nil {
+		env.RemoveFiles()
 		return fmt.Errorf("failed to execute command: %s", err)
 	}
 	return nil
 }
 
+type resolveCommand struct {
+	resolveOptions
+	Format         string `long:"format" default:"dotenv" choice:"dotenv" choice:"sh" choice:"json" choice:"systemd" description:"Output format."`
+	OnlyReferences bool   `long:"only-references" description:"Only print variables that referenced secrets (or were expanded from a reference)."`
+}
+
+// Execute the resolve subcommand.
+func (c *resolveCommand) Execute(args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(args, " "))
+	}
+
+	vars, err := c.environ()
+	if err != nil {
+		return err
+	}
+
+	_, resolved, err := c.resolve(vars)
+	if err != nil {
+		return err
+	}
+	if c.OnlyReferences {
+		resolved = changed(vars, resolved)
+	}
+
+	out, err := format(c.Format, resolved)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stdout.WriteString(out); err != nil {
+		return fmt.Errorf("failed to write output: %s", err)
+	}
+	return nil
+}
+
+// changed returns the variables in resolved that are not present in env with the same value.
+func changed(env, resolved []string) []string {
+	unchanged := make(map[string]bool, len(env))
+	for _, v := range env {
+		unchanged[v] = true
+	}
+	var out []string
+	for _, v := range resolved {
+		if !unchanged[v] {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// format the environment ("key=value" pairs) for the given output format.
+func format(f string, env []string) (string, error) {
+	if f == "json" {
+		o := make(map[string]string, len(env))
+		for _, v := range env {
+			name, value, _ := strings.Cut(v, "=")
+			o[name] = value
+		}
+		b, err := json.MarshalIndent(o, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal json: %s", err)
+		}
+		return string(b) + "\n", nil
+	}
+
+	var b strings.Builder
+	for _, v := range env {
+		name, value, _ := strings.Cut(v, "=")
+		// Inherited variables can have names that are not valid identifiers (e.g. exported bash
+		// functions), which are skipped since they would break the output for the other variables.
+		if !identifier(name) {
+			continue
+		}
+		switch f {
+		case "sh":
+			// Single quotes preserve every character, except for single
+			// quotes which are closed, escaped and reopened.
+			fmt.Fprintf(&b, "export %s='%s'\n", name, strings.ReplaceAll(value, "'", `'\''`))
+		case "systemd":
+			fmt.Fprintf(&b, "%s=\"%s\"\n", name, systemdEscaper.Replace(value))
+		default:
+			if strings.ContainsAny(value, " \t\n\r\"'\\#$`") {
+				value = "\"" + dotenvEscaper.Replace(value) + "\""
+			}
+			fmt.Fprintf(&b, "%s=%s\n", name, value)
+		}
+	}
+	return b.String(), nil
+}
+
+// identifier returns true if the name only contains letters, digits and underscores,
+// and does not start with a digit.
+func identifier(name string) bool {
+	for i, c := range name {
+		switch {
+		case c == '_', c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return name != ""
+}
+
+var (
+	dotenvEscaper  = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "\n", `\n`, "\r", `\r`)
+	systemdEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`")
+)
+
+type checkCommand struct {
+	resolveOptions
+}
+
+// Execute the check subcommand.
+func (c *checkCommand) Execute(args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(args, " "))
+	}
+
+	vars, err := c.environ()
+	if err != nil {
+		return err
+	}
+
+	env, err := c.manager()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := c.context()
+	defer cancel()
+
+	results, err := env.Check(ctx, vars)
+	if err != nil {
+		return fmt.Errorf("failed to check environment: %s", err)
+	}
+
+	var failed environment.ReferenceErrors
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VARIABLE\tREFERENCE\tSTATUS")
+	for _, r := range results {
+		status := "ok"
+		if r.Err != nil {
+			// AWS errors can span several lines (e.g. "caused by: ...").
+			status = "FAILED: " + strings.Join(strings.Fields(r.Err.Err.Error()), " ")
+			failed = append(failed, r.Err)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", r.Name, r.Reference, status)
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to write output: %s", err)
+	}
+
+	if len(failed) > 0 {
+		return &checkError{errs: failed, total: len(results)}
+	}
+	return nil
+}
+
+// checkError is returned when references fail the check. The failures have already
+// been printed, but are wrapped so that they determine the exit code.
+type checkError struct {
+	errs  environment.ReferenceErrors
+	total int
+}
+
+func (e *checkError) Error() string {
+	return fmt.Sprintf("%d of %d references failed the check", len(e.errs), e.total)
+}
+
+func (e *checkError) Unwrap() error {
+	return e.errs
+}
+
+// readEnvFiles reads the variables from dotenv files, in order.
+func readEnvFiles(paths []string) ([]string, error) {
+	var env []string
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read env file: %s", err)
+		}
+		vars, err := parseEnvFile(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse env file: %s: %s", path, err)
+		}
+		env = append(env, vars...)
+	}
+	return env, nil
+}
+
+// parseEnvFile parses "key=value" pairs from a dotenv file (e.g. written by the resolve
+// subcommand). Blank lines, comments and "export" prefixes are ignored, and values can be
+// single quoted (literal) or double quoted (with escapes), in which case they can span
+// several lines. Unquoted values are used as is, since "#" is part of a reference. Files with
+// CRLF line endings are supported, and line breaks in quoted values are read as "\n".
+func parseEnvFile(data string) ([]string, error) {
+	data = strings.ReplaceAll(data, "\r\n", "\n")
+
+	var env []string
+	for line := 1; data != ""; line++ {
+		var l string
+		l, data, _ = strings.Cut(data, "\n")
+		l = strings.TrimLeft(l, " \t")
+		if strings.TrimSpace(l) == "" || strings.HasPrefix(l, "#") {
+			continue
+		}
+
+		// Trailing whitespace is only trimmed from unquoted values, since
+		// quoted values can continue on the next line.
+		name, value, ok := strings.Cut(strings.TrimPrefix(l, "export "), "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("line %d: expected key=value", line)
+		}
+		value = strings.TrimLeft(value, " \t")
+
+		if value != "" && (value[0] == '"' || value[0] == '\'') {
+			rest := value[1:] + "\n" + data
+			v, n, err := unquote(rest, value[0])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %s", line, err)
+			}
+			var trailing string
+			line += strings.Count(rest[:n], "\n")
+			trailing, data, _ = strings.Cut(rest[n:], "\n")
+			if t := strings.TrimSpace(trailing); t != "" && !strings.HasPrefix(t, "#") {
+				return nil, fmt.Errorf("line %d: unexpected characters after closing quote", line)
+			}
+			value = v
+		} else {
+			value = strings.TrimSpace(value)
+		}
+		env = append(env, name+"="+value)
+	}
+	return env, nil
+}
+
+// unquote returns the value up to the closing quote, and the index after the closing quote.
+// Backslash escapes are supported in double quoted values.
+func unquote(s string, quote byte) (string, int, error) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == quote:
+			return b.String(), i + 1, nil
+		case c == '\\' && quote == '"' && i+1 < len(s):
+			i++
+			switch s[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			default:
+				b.WriteByte(s[i])
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", 0, fmt.Errorf("missing closing quote: %c", quote)
+}
+
+type encryptCommand struct {
+	awsOptions
+	KeyID   string            `long:"key-id" required:"true" description:"KMS key ID, key ARN, alias name (e.g. alias/app) or alias ARN to encrypt with."`
+	Context map[string]string `long:"context" key-value-delimiter:"=" description:"Encryption context as key=value (can be repeated)."`
+}
+
+// Execute the encrypt subcommand.
+func (c *encryptCommand) Execute(args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(args, " "))
+	}
+
+	plaintext, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return fmt.Errorf("failed to read plaintext from stdin: %s", err)
+	}
+	if len(plaintext) == 0 {
+		return errors.New("please supply the plaintext on stdin")
+	}
+
+	env, err := c.manager()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := c.context()
+	defer cancel()
+
+	ref, err := env.Encrypt(ctx, c.KeyID, plaintext, c.Context)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt: %w", err)
+	}
+	fmt.Println(ref)
+	return nil
+}
+
+type decryptCommand struct {
+	awsOptions
+}
+
+// Execute the decrypt subcommand.
+func (c *decryptCommand) Execute(args []string) error {
+	if len(args) != 1 || !strings.HasPrefix(args[0], "kms://") {
+		return errors.New("please supply a kms:// reference to decrypt")
+	}
+
+	env, err := c.manager()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := c.context()
+	defer cancel()
+
+	resolved, err := env.ResolveContext(ctx, []string{"SECRET=" + args[0]})
+	if err != nil {
+		return fmt.Errorf("failed to decrypt: %w", err)
+	}
+	_, secret, _ := strings.Cut(resolved[0], "=")
+	if _, err := os.Stdout.WriteString(secret); err != nil {
+		return fmt.Errorf("failed to write output: %s", err)
+	}
+	return nil
+}
+
+type putCommand struct {
+	awsOptions
+	KMSKeyID string `long:"kms-key-id" description:"KMS key used to encrypt parameters and new secrets (defaults to the key of an existing parameter, or the AWS managed key)."`
+}
+
+// Execute the put subcommand.
+func (c *putCommand) Execute(args []string) error {
+	if len(args) != 1 {
+		return errors.New("please supply an sm:// or ssm:// reference to write to")
+	}
+
+	value, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return fmt.Errorf("failed to read value from stdin: %s", err)
+	}
+
+	env, err := c.manager()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := c.context()
+	defer cancel()
+
+	if err := env.Put(ctx, args[0], string(value), c.KMSKeyID); err != nil {
+		return fmt.Errorf("failed to put secret: %w", err)
+	}
+	return nil
+}
+
+// exitCodes for errors that entrypoint scripts might want to react to. If more than one
+// secret failed to resolve, the exit code is determined by the first failing variable.
+var exitCodes = []struct {
+	err  error
+	code int
+}{
+	{err: environment.ErrNotFound, code: 3},
+	{err: environment.ErrAccessDenied, code: 4},
+	{err: environment.ErrThrottled, code: 5},
+	{err: environment.ErrInvalidReference, code: 6},
+	{err: environment.ErrMissingKey, code: 7},
+}
+
+func exitCode(err error) int {
+	var errs environment.ReferenceErrors
+	if errors.As(err, &errs) && len(errs) > 0 {
+		err = errs[0]
+	}
+	for _, e := range exitCodes {
+		if errors.Is(err, e.err) {
+			return e.code
+		}
+	}
+	return 1
+}
+
 func init() {
 	command.Version = func() {
 		fmt.Println(version)
@@ -65,7 +566,7 @@ func main() {
 		if flagsErr, ok := err.(*flags.Error); ok && flagsErr.Type == flags.ErrHelp {
 			os.Exit(0)
 		} else {
-			os.Exit(1)
+			os.Exit(exitCode(err))
 		}
 	}
 
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var formatValues = []string{
+	"plain",
+	"",
+	"it's",
+	"line one\nline two\r\n",
+	"$HOME ${HOME} $(id)",
+	"`id`",
+	`C:\path\to\"file"\n`,
+	" # leading space and hash",
+}
+
+func TestFormatShell(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh is not installed")
+	}
+
+	for _, value := range formatValues {
+		out, err := format("sh", []string{"BASH_FUNC_x%%=() { :; }", "VALUE=" + value})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		b, err := exec.Command(sh, "-c", `eval "$1" && printf '%s' "$VALUE"`, "sh", out).Output()
+		if err != nil {
+			t.Fatalf("failed to evaluate %q: %s", out, err)
+		}
+		eq(t, value, string(b))
+	}
+}
+
+func TestFormatDotenv(t *testing.T) {
+	for _, value := range formatValues {
+		out, err := format("dotenv", []string{"1INVALID=value", "VALUE=" + value})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		env, err := parseEnvFile(out)
+		if err != nil {
+			t.Fatalf("failed to parse %q: %s", out, err)
+		}
+		eq(t, []string{"VALUE=" + value}, env)
+	}
+}
+
+func TestFormatSystemd(t *testing.T) {
+	out, err := format("systemd", []string{"BASH_FUNC_x%%=() { :; }", "VALUE=it's \"$HOME\" `id` \\n"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	eq(t, "VALUE=\"it's \\\"\\$HOME\\\" \\`id\\` \\\\n\"\n", out)
+}
+
+func TestEnviron(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first.env")
+	second := filepath.Join(dir, "second.env")
+	if err := os.WriteFile(first, []byte("INHERITED=first\nFIRST=first\nBOTH=first\n"), 0600); err != nil {
+		t.Fatalf("failed to write env file: %s", err)
+	}
+	if err := os.WriteFile(second, []byte("BOTH=second\nSECOND=second\n"), 0600); err != nil {
+		t.Fatalf("failed to write env file: %s", err)
+	}
+	t.Setenv("INHERITED", "inherited")
+	t.Setenv("UNCHANGED", "inherited")
+
+	tests := []struct {
+		description string
+		options     resolveOptions
+		expect      map[string]string
+	}{
+		{
+			description: "uses the inherited environment",
+			expect:      map[string]string{"INHERITED": "inherited", "UNCHANGED": "inherited"},
+		},
+		{
+			description: "gives env files precedence over the inherited environment",
+			options:     resolveOptions{EnvFiles: []string{first, second}},
+			expect: map[string]string{
+				"INHERITED": "first",
+				"UNCHANGED": "inherited",
+				"FIRST":     "first",
+				"SECOND":    "second",
+				"BOTH":      "second",
+			},
+		},
+		{
+			description: "gives later env files precedence",
+			options:     resolveOptions{EnvFiles: []string{second, first}},
+			expect:      map[string]string{"BOTH": "first"},
+		},
+		{
+			description: "only uses the env files if the environment is not inherited",
+			options:     resolveOptions{EnvFiles: []string{first, second}, NoInherit: true},
+			expect: map[string]string{
+				"INHERITED": "first",
+				"UNCHANGED": "",
+				"FIRST":     "first",
+				"SECOND":    "second",
+				"BOTH":      "second",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			env, err := tc.options.environ()
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			for name, value := range tc.expect {
+				eq(t, value, lookup(env, name))
+			}
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	env := merge([]string{"A=1", "B=2", "C=3"}, []string{"B=file", "D=file", "A=file", "D=last"})
+	eq(t, []string{"A=file", "B=file", "C=3", "D=last"}, env)
+}
+
+func TestParseEnvFile(t *testing.T) {
+	tests := []struct {
+		description string
+		data        string
+		expect      []string
+		expectError bool
+	}{
+		{
+			description: "parses unquoted values as is",
+			data:        "A=1\nREF=sm://app/db#password\nSPACE= value \n",
+			expect:      []string{"A=1", "REF=sm://app/db#password", "SPACE=value"},
+		},
+		{
+			description: "ignores blank lines and comments",
+			data:        "\n# comment\n  # indented comment\nA=1\n\n",
+			expect:      []string{"A=1"},
+		},
+		{
+			description: "ignores export prefixes",
+			data:        "export A=1\nexport B='2'\n",
+			expect:      []string{"A=1", "B=2"},
+		},
+		{
+			description: "supports empty values",
+			data:        "A=\nB=''\nC=\"\"\n",
+			expect:      []string{"A=", "B=", "C="},
+		},
+		{
+			description: "takes single quoted values literally",
+			data:        `A='$HOME \n "quoted" # not a comment'`,
+			expect:      []string{`A=$HOME \n "quoted" # not a comment`},
+		},
+		{
+			description: "supports escapes in double quoted values",
+			data:        `A="line\none\ttab \"quoted\" \$HOME \\"`,
+			expect:      []string{"A=line\none\ttab \"quoted\" $HOME \\"},
+		},
+		{
+			description: "supports values that span several lines",
+			data:        "A='first\nsecond'\nB=\"third\nfourth\"\nC=3\n",
+			expect:      []string{"A=first\nsecond", "B=third\nfourth", "C=3"},
+		},
+		{
+			description: "preserves trailing whitespace in values that span several lines",
+			data:        "A=\"foo   \nbar\"\nB='baz \t\nqux'\n",
+			expect:      []string{"A=foo   \nbar", "B=baz \t\nqux"},
+		},
+		{
+			description: "allows comments after a closing quote",
+			data:        "A='1' # comment\nB=\"2\"  \n",
+			expect:      []string{"A=1", "B=2"},
+		},
+		{
+			description: "fails on characters after a closing quote",
+			data:        "A='1'2\n",
+			expectError: true,
+		},
+		{
+			description: "fails on missing closing quotes",
+			data:        "A='1\nB=2\n",
+			expectError: true,
+		},
+		{
+			description: "fails on lines without a key",
+			data:        "=1\n",
+			expectError: true,
+		},
+		{
+			description: "fails on lines without a value",
+			data:        "A\n",
+			expectError: true,
+		},
+		{
+			description: "supports CRLF line endings",
+			data:        "A=1\r\nB='2'\r\nC=\"3\" # comment\r\nD='first\r\nsecond\r\nthird'\r\n",
+			expect:      []string{"A=1", "B=2", "C=3", "D=first\nsecond\nthird"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			env, err := parseEnvFile(tc.data)
+			if tc.expectError {
+				if err == nil {
+					t.Fatalf("expected an error, got: %q", env)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			eq(t, tc.expect, env)
+		})
+	}
+}
+
+func lookup(env []string, name string) string {
+	var value string
+	for _, v := range env {
+		if k, v, _ := strings.Cut(v, "="); k == name {
+			value = v
+		}
+	}
+	return value
+}
+
+func eq(t *testing.T, expected, got interface{}) {
+	t.Helper()
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("\nexpected:\n%v\n\ngot:\n%v", expected, got)
+	}
+}
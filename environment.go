@@ -1,15 +1,18 @@
 package environment
 
 import (
-	"encoding/base64"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/ec2metadata"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/kms"
 	"github.com/aws/aws-sdk-go/service/secretsmanager"
@@ -17,48 +20,130 @@ import (
 )
 
 const (
-	smPrefix     = "sm://"
-	ssmPrefix    = "ssm://"
-	kmsPrefix    = "kms://"
-	envDelmiter  = "="
-	mvsDelimiter = "#"
+	schemeDelimiter = "://"
+	envDelmiter     = "="
+	mvsDelimiter    = "#"
 )
 
+// DefaultConcurrency is the number of secrets resolved in parallel unless
+// configured otherwise using WithConcurrency.
+const DefaultConcurrency = 10
+
 // Generate test fakes.
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -generate
 
 // SMClient (secrets manager client) for testing purposes.
 //counterfeiter:generate -o ./fakes . SMClient
 type SMClient interface {
-	GetSecretValue(*secretsmanager.GetSecretValueInput) (*secretsmanager.GetSecretValueOutput, error)
+	GetSecretValueWithContext(aws.Context, *secretsmanager.GetSecretValueInput, ...request.Option) (*secretsmanager.GetSecretValueOutput, error)
+	BatchGetSecretValueWithContext(aws.Context, *secretsmanager.BatchGetSecretValueInput, ...request.Option) (*secretsmanager.BatchGetSecretValueOutput, error)
+	PutSecretValueWithContext(aws.Context, *secretsmanager.PutSecretValueInput, ...request.Option) (*secretsmanager.PutSecretValueOutput, error)
+	CreateSecretWithContext(aws.Context, *secretsmanager.CreateSecretInput, ...request.Option) (*secretsmanager.CreateSecretOutput, error)
 }
 
 // SSMClient for testing purposes.
 //counterfeiter:generate -o ./fakes . SSMClient
 type SSMClient interface {
-	GetParameter(*ssm.GetParameterInput) (*ssm.GetParameterOutput, error)
+	GetParameterWithContext(aws.Context, *ssm.GetParameterInput, ...request.Option) (*ssm.GetParameterOutput, error)
+	GetParametersWithContext(aws.Context, *ssm.GetParametersInput, ...request.Option) (*ssm.GetParametersOutput, error)
+	GetParametersByPathWithContext(aws.Context, *ssm.GetParametersByPathInput, ...request.Option) (*ssm.GetParametersByPathOutput, error)
+	PutParameterWithContext(aws.Context, *ssm.PutParameterInput, ...request.Option) (*ssm.PutParameterOutput, error)
+	DescribeParametersWithContext(aws.Context, *ssm.DescribeParametersInput, ...request.Option) (*ssm.DescribeParametersOutput, error)
 }
 
 // KMSClient for testing purposes.
 //counterfeiter:generate -o ./fakes . KMSClient
 type KMSClient interface {
-	Decrypt(*kms.DecryptInput) (*kms.DecryptOutput, error)
+	DecryptWithContext(aws.Context, *kms.DecryptInput, ...request.Option) (*kms.DecryptOutput, error)
+	EncryptWithContext(aws.Context, *kms.EncryptInput, ...request.Option) (*kms.EncryptOutput, error)
 }
 
 // NewTestManager for testing purposes.
-func NewTestManager(sm SMClient, ssm SSMClient, kms KMSClient) *Manager {
-	return &Manager{sm: sm, ssm: ssm, kms: kms}
+func NewTestManager(sm SMClient, ssm SSMClient, kms KMSClient, opts ...Option) *Manager {
+	return newManager(sm, ssm, kms, opts)
 }
 
 // Manager handles API calls to AWS.
 type Manager struct {
-	sm  SMClient
-	ssm SSMClient
-	kms KMSClient
+	sm             SMClient
+	ssm            SSMClient
+	kms            KMSClient
+	resolvers      map[string]Resolver
+	concurrency    int
+	requestTimeout time.Duration
+	files          *fileWriter
+	fileSuffix     string
+}
+
+// Option for configuring the Manager.
+type Option func(*Manager)
+
+// WithConcurrency sets the maximum number of secrets that are resolved in
+// parallel. Values less than 1 are treated as 1.
+func WithConcurrency(n int) Option {
+	return func(m *Manager) {
+		if n < 1 {
+			n = 1
+		}
+		m.concurrency = n
+	}
+}
+
+// WithRequestTimeout sets a timeout for each individual request to AWS. The
+// default is to only rely on the context passed to e.g. PopulateContext.
+func WithRequestTimeout(d time.Duration) Option {
+	return func(m *Manager) {
+		m.requestTimeout = d
+	}
+}
+
+// WithFileDirectory sets the directory used for secrets that are written to
+// files (e.g. binary secrets with ?binary=file). Defaults to os.TempDir.
+func WithFileDirectory(dir string) Option {
+	return func(m *Manager) {
+		m.files.dir = dir
+	}
+}
+
+// WithFileSuffix writes the secrets referenced by environment variables with the given
+// suffix (e.g. "_FILE") to files, as if the references used ?file. Disabled by default.
+func WithFileSuffix(suffix string) Option {
+	return func(m *Manager) {
+		m.fileSuffix = suffix
+	}
+}
+
+func newManager(sm SMClient, ssm SSMClient, kms KMSClient, opts []Option) *Manager {
+	m := &Manager{
+		sm:          sm,
+		ssm:         ssm,
+		kms:         kms,
+		resolvers:   make(map[string]Resolver),
+		concurrency: DefaultConcurrency,
+		files:       &fileWriter{},
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	// The built-in resolvers are only registered if they have not been replaced
+	// using WithResolver, and after all options have been applied so that they
+	// can use the configured request timeout.
+	builtin := map[string]Resolver{
+		smScheme:  &smResolver{client: sm, timeout: m.requestTimeout, files: m.files},
+		ssmScheme: &ssmResolver{client: ssm, timeout: m.requestTimeout},
+		kmsScheme: &kmsResolver{client: kms, timeout: m.requestTimeout},
+	}
+	for scheme, r := range builtin {
+		if _, ok := m.resolvers[scheme]; !ok {
+			m.resolvers[scheme] = r
+		}
+	}
+	return m
 }
 
 // New creates a new manager for populating secret values.
-func New(sess *session.Session) (*Manager, error) {
+func New(sess *session.Session, opts ...Option) (*Manager, error) {
 	var config *aws.Config
 
 	if os.Getenv("AWS_REGION") == "" && os.Getenv("AWS_DEFAULT_REGION") == "" {
@@ -73,115 +158,455 @@ func New(sess *session.Session) (*Manager, error) {
 		config = &aws.Config{Region: aws.String(region)}
 	}
 
-	return &Manager{
-		sm:  secretsmanager.New(sess, config),
-		ssm: ssm.New(sess, config),
-		kms: kms.New(sess, config),
-	}, nil
+	return newManager(
+		secretsmanager.New(sess, config),
+		ssm.New(sess, config),
+		kms.New(sess, config),
+		opts,
+	), nil
 }
 
 // Populate environment variables with their secret values from either Secrets manager, SSM Parameter store or KMS.
+// Secrets are resolved in parallel, and the environment is only updated if all of them were resolved successfully.
 func (m *Manager) Populate() error {
-	env := make(map[string]string)
-	for _, v := range os.Environ() {
-		var (
-			found  bool
-			secret string
-			err    error
-		)
+	return m.PopulateContext(context.Background())
+}
 
-		name, value, ok := strings.Cut(v, envDelmiter)
-		if !ok {
-			return fmt.Errorf("failed to parse environment variable with delimiter: %q", envDelmiter)
+// PopulateContext is the same as Populate with the addition of a context, which is used
+// for cancellation and deadlines for all requests to AWS.
+func (m *Manager) PopulateContext(ctx context.Context) error {
+	env := os.Environ()
+	resolved, err := m.ResolveContext(ctx, env)
+	if err != nil {
+		return err
+	}
+
+	current := make(map[string]string, len(env))
+	for _, v := range env {
+		name, value, _ := strings.Cut(v, envDelmiter)
+		current[name] = value
+	}
+	for _, v := range resolved {
+		name, secret, _ := strings.Cut(v, envDelmiter)
+		if value, ok := current[name]; ok && value == secret {
+			delete(current, name)
+			continue
 		}
+		delete(current, name)
+		if err := os.Setenv(name, secret); err != nil {
+			return fmt.Errorf("failed to set environment variable: '%s': %s", name, err)
+		}
+	}
 
-		// # is not a legal character in secrets manager, parameter store or an
-		// encrypted (and base64 encoded) string from KMS. I.e. it should only
-		// be present if we are dealing with a multi-value secret.
-		path, secretKey, isMultiValueSecret := strings.Cut(value, mvsDelimiter)
+	// Any remaining variables were references that expanded into other variables.
+	for name := range current {
+		if err := os.Unsetenv(name); err != nil {
+			return fmt.Errorf("failed to unset environment variable: '%s': %s", name, err)
+		}
+	}
+	return nil
+}
 
-		if strings.HasPrefix(path, ssmPrefix) {
-			secret, err = m.getParameter(strings.TrimPrefix(path, ssmPrefix))
+// Resolve the secret values referenced in env, which uses the same "key=value" format as os.Environ. The
+// returned environment is a copy of env where references have been replaced with their secret values, which
+// means that Resolve can be used without modifying the environment of the current process (e.g. for exec.Cmd).
+//
+// References to a path (e.g. ssm:///app/prod/) are replaced by one variable per secret below the path, which
+// are appended to the environment unless a variable with the same name is already defined in env. References
+// can also be embedded in a larger value using "${<reference>}", e.g. postgres://app:${sm://db#password}@host/db.
+func (m *Manager) Resolve(env []string) ([]string, error) {
+	return m.ResolveContext(context.Background(), env)
+}
+
+// ResolveContext is the same as Resolve with the addition of a context, which is used
+// for cancellation and deadlines for all requests to AWS.
+func (m *Manager) ResolveContext(ctx context.Context, env []string) ([]string, error) {
+	refs, interpolations, err := m.parseEnvironment(env)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.resolve(ctx, refs); err != nil {
+		return nil, err
+	}
+
+	var (
+		resolved = make([]string, len(env))
+		removed  = make(map[int]bool)
+		expanded = make(map[string]string)
+		names    []string
+	)
+	copy(resolved, env)
+	for _, in := range interpolations {
+		value := in.value()
+		if in.file {
+			path, err := m.files.write(ctx, []byte(value))
 			if err != nil {
-				return fmt.Errorf("failed to get secret from parameter store: %q: %s", name, err)
+				return nil, fmt.Errorf("failed to write environment variable to file: '%s': %s", in.name, err)
 			}
-			found = true
+			value = path
 		}
-		if strings.HasPrefix(path, smPrefix) {
-			secret, err = m.getSecretValue(strings.TrimPrefix(path, smPrefix))
-			if err != nil {
-				return fmt.Errorf("failed to get secret from secret manager: %q: %s", name, err)
+		resolved[in.index] = in.name + envDelmiter + value
+	}
+	for _, ref := range refs {
+		if ref.embedded {
+			continue
+		}
+		if !ref.expands() {
+			resolved[ref.index] = ref.name + envDelmiter + ref.secret
+			continue
+		}
+		removed[ref.index] = true
+		for _, name := range sortedKeys(ref.secrets) {
+			if _, ok := expanded[name]; !ok {
+				names = append(names, name)
 			}
-			found = true
+			expanded[name] = ref.secrets[name]
 		}
-		if strings.HasPrefix(path, kmsPrefix) {
-			secret, err = m.decrypt(strings.TrimPrefix(path, kmsPrefix))
-			if err != nil {
-				return fmt.Errorf("failed to decrypt kms secret: %q: %s", name, err)
+	}
+
+	out := make([]string, 0, len(env)+len(names))
+	for i, v := range resolved {
+		if removed[i] {
+			continue
+		}
+		name, _, _ := strings.Cut(v, envDelmiter)
+		delete(expanded, name)
+		out = append(out, v)
+	}
+	for _, name := range names {
+		if secret, ok := expanded[name]; ok {
+			out = append(out, name+envDelmiter+secret)
+		}
+	}
+	return out, nil
+}
+
+// RemoveFiles removes the files that secrets have been written to (using ?file, ?binary=file or
+// WithFileSuffix). Files are not removed automatically since they are typically read by another
+// process (e.g. a command that is started with the resolved environment), but should be removed
+// once they are no longer needed.
+func (m *Manager) RemoveFiles() error {
+	return m.files.removeAll()
+}
+
+// parseEnvironment returns the references in env (in order), including references that are
+// embedded in the values of the returned interpolations.
+func (m *Manager) parseEnvironment(env []string) ([]*reference, []*interpolation, error) {
+	var (
+		refs           []*reference
+		interpolations []*interpolation
+	)
+	for i, v := range env {
+		name, value, ok := strings.Cut(v, envDelmiter)
+		if !ok {
+			return nil, nil, fmt.Errorf("failed to parse environment variable with delimiter: %q", envDelmiter)
+		}
+		if ref := m.parseReference(name, value, false); ref != nil {
+			ref.index = i
+			refs = append(refs, ref)
+		} else if in := m.parseInterpolation(name, value); in != nil {
+			in.index = i
+			interpolations = append(interpolations, in)
+			refs = append(refs, in.refs...)
+		}
+	}
+	return refs, interpolations, nil
+}
+
+// lookup of a distinct secret in one of the backends. References that share a
+// lookup (e.g. different keys of the same multi-value secret) are fetched once.
+type lookup struct {
+	scheme string
+	path   string
+	expand bool
+}
+
+// result of a lookup.
+type result struct {
+	value   string
+	secrets map[string]string
+	err     error
+}
+
+// resolve the references using a bounded number of workers. If any of the references
+// fail the returned ReferenceErrors has the same order as the references, regardless
+// of the order they completed in.
+func (m *Manager) resolve(ctx context.Context, refs []*reference) error {
+	var (
+		lookups []lookup
+		index   = make(map[lookup]int)
+	)
+	for _, ref := range refs {
+		if ref.err != nil {
+			continue
+		}
+		if _, ok := index[ref.lookup()]; !ok {
+			index[ref.lookup()] = len(lookups)
+			lookups = append(lookups, ref.lookup())
+		}
+	}
+
+	results := m.fetch(ctx, lookups)
+
+	var failed ReferenceErrors
+	for _, ref := range refs {
+		err := ref.err
+		if err == nil {
+			res := results[index[ref.lookup()]]
+			switch {
+			case res.err != nil:
+				err = res.err
+			case ref.expand:
+				ref.secrets, err = expandNames(ref, res.secrets)
+			case ref.expandJSON:
+				ref.secrets, err = extractAll(ref, res.value)
+			default:
+				ref.secret, err = extract(ref, res.value)
+			}
+			if err == nil {
+				err = ref.applyTransforms(ref.file || ref.embedded && m.writesFile(ref.name))
 			}
-			found = true
 		}
-		if found {
-			if isMultiValueSecret {
-				o := make(map[string]string)
-				if err := json.Unmarshal([]byte(secret), &o); err != nil {
-					return fmt.Errorf("failed to unmarshal multi-value secret: %q", name)
-				}
+		err = classify(err)
+		if ref.optional && (errors.Is(err, ErrNotFound) || errors.Is(err, ErrMissingKey)) {
+			ref.secret, ref.secrets, err = ref.defaultValue, nil, nil
+		}
+		if err == nil && ref.file {
+			ref.secret, err = m.files.write(ctx, []byte(ref.secret))
+		}
+		if err != nil {
+			ref.failed = &ReferenceError{
+				Name:    ref.name,
+				Backend: ref.scheme,
+				Path:    ref.path,
+				Err:     err,
+			}
+			failed = append(failed, ref.failed)
+		}
+	}
+	if len(failed) > 0 {
+		return failed
+	}
+	return nil
+}
 
-				secret, ok = o[secretKey]
-				if !ok {
-					return fmt.Errorf("failed to get multi-value secret with key (%q): %q", secretKey, name)
+// fetch the secret value for each lookup using a bounded number of workers.
+func (m *Manager) fetch(ctx context.Context, lookups []lookup) []result {
+	var (
+		results = make([]result, len(lookups))
+		jobs    = make(chan []int)
+		wg      sync.WaitGroup
+	)
+
+	batches := m.batch(lookups)
+	workers := m.concurrency
+	if workers > len(batches) {
+		workers = len(batches)
+	}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for indices := range jobs {
+				// Skip the remaining batches if the context has been cancelled.
+				if err := ctx.Err(); err != nil {
+					for _, j := range indices {
+						results[j].err = err
+					}
+					continue
 				}
+				m.fetchBatch(ctx, lookups, indices, results)
 			}
-			env[name] = secret
+		}()
+	}
+	for _, indices := range batches {
+		jobs <- indices
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+// batch groups the lookups (by index) into units of work. Lookups for resolvers
+// that implement BatchResolver are grouped into batches of up to BatchSize, while
+// all other lookups (and paths that cannot be batched) are resolved one at a time.
+func (m *Manager) batch(lookups []lookup) [][]int {
+	var (
+		batches [][]int
+		schemes []string
+		pending = make(map[string][]int)
+	)
+	for i, l := range lookups {
+		r, ok := m.resolvers[l.scheme].(BatchResolver)
+		if f, filters := r.(batchFilter); ok && filters && !f.batchable(l.path) {
+			ok = false
+		}
+		if !ok || l.expand || r.BatchSize() <= 1 {
+			batches = append(batches, []int{i})
+			continue
+		}
+		if _, ok := pending[l.scheme]; !ok {
+			schemes = append(schemes, l.scheme)
+		}
+		pending[l.scheme] = append(pending[l.scheme], i)
+		if len(pending[l.scheme]) == r.BatchSize() {
+			batches = append(batches, pending[l.scheme])
+			pending[l.scheme] = []int{}
+		}
+	}
+	for _, scheme := range schemes {
+		if len(pending[scheme]) > 0 {
+			batches = append(batches, pending[scheme])
 		}
 	}
+	return batches
+}
 
-	for name, secret := range env {
-		if err := os.Setenv(name, secret); err != nil {
-			return fmt.Errorf("failed to set environment variable: '%s': %s", name, err)
+// fetchBatch fetches the lookups for the given indices and stores the results
+// at the same indices in results.
+func (m *Manager) fetchBatch(ctx context.Context, lookups []lookup, indices []int, results []result) {
+	l := lookups[indices[0]]
+	r := m.resolvers[l.scheme]
+	if len(indices) == 1 {
+		j := indices[0]
+		if l.expand {
+			results[j].secrets, results[j].err = r.(Expander).Expand(ctx, l.path)
+			return
 		}
+		results[j].value, results[j].err = r.Resolve(ctx, l.path)
+		return
+	}
+
+	paths := make([]string, len(indices))
+	for i, j := range indices {
+		paths[i] = lookups[j].path
+	}
+	values, errs := r.(BatchResolver).ResolveBatch(ctx, paths)
+	for i, j := range indices {
+		if len(values) != len(paths) || len(errs) != len(paths) {
+			results[j].err = fmt.Errorf("resolver returned %d values and %d errors for %d paths", len(values), len(errs), len(paths))
+			continue
+		}
+		results[j].value, results[j].err = values[i], errs[i]
 	}
-	return nil
 }
 
-func (m *Manager) getSecretValue(path string) (out string, err error) {
-	res, err := m.sm.GetSecretValue(&secretsmanager.GetSecretValueInput{SecretId: aws.String(path)})
+// extract the secret for a reference from the value of its lookup. The key of
+// a multi-value reference is looked up as a top-level key first, and otherwise
+// evaluated as a JMESPath expression (e.g. "db.hosts[0]").
+func extract(ref *reference, secret string) (string, error) {
+	if !ref.multiValue {
+		return secret, nil
+	}
+
+	o, err := unmarshalMultiValue(secret)
 	if err != nil {
 		return "", err
 	}
 
-	if res.SecretString != nil {
-		out = aws.StringValue(res.SecretString)
-	} else {
-		var data []byte
-		if _, err := base64.StdEncoding.Decode(data, res.SecretBinary); err != nil {
-			return "", fmt.Errorf("failed to decode binary secret: %s", err)
+	if v, ok := o[ref.key]; ok {
+		return jsonValue(v), nil
+	}
+	if ref.query == nil {
+		return "", fmt.Errorf("%w: %q", ErrMissingKey, ref.key)
+	}
+
+	var data interface{}
+	d := json.NewDecoder(strings.NewReader(secret))
+	d.UseNumber()
+	if err := d.Decode(&data); err != nil {
+		return "", fmt.Errorf("%w: secret is not a JSON object", ErrInvalidReference)
+	}
+	// The error from evaluating the expression is omitted, since it can contain the secret.
+	v, err := ref.query.Search(data)
+	if err != nil {
+		return "", fmt.Errorf("%w: failed to evaluate %q", ErrInvalidReference, ref.key)
+	}
+	if v == nil {
+		return "", fmt.Errorf("%w: %q", ErrMissingKey, ref.key)
+	}
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal value of %q: %s", ref.key, err)
+	}
+	return string(b), nil
+}
+
+// extractAll returns the values for all keys (or the allowed keys) of a multi-value
+// secret, keyed by the name of the environment variable.
+func extractAll(ref *reference, secret string) (map[string]string, error) {
+	o, err := unmarshalMultiValue(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := ref.expandOptions.keys
+	if keys == nil {
+		keys = sortedKeys(o)
+	}
+
+	var (
+		out     = make(map[string]string, len(keys))
+		sources = make(map[string]string, len(keys))
+	)
+	for _, key := range keys {
+		v, ok := o[key]
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", ErrMissingKey, key)
+		}
+		if err := setExpanded(out, sources, ref.expandOptions.envName("", key), key, jsonValue(v)); err != nil {
+			return nil, err
 		}
-		out = string(data)
 	}
 	return out, nil
 }
 
-func (m *Manager) getParameter(path string) (string, error) {
-	res, err := m.ssm.GetParameter(&ssm.GetParameterInput{
-		Name:           aws.String(path),
-		WithDecryption: aws.Bool(true),
-	})
-	if err != nil {
-		return "", err
+func unmarshalMultiValue(secret string) (map[string]json.RawMessage, error) {
+	var o map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(secret), &o); err != nil || o == nil {
+		return nil, fmt.Errorf("%w: secret is not a JSON object", ErrInvalidReference)
 	}
-	return aws.StringValue(res.Parameter.Value), nil
+	return o, nil
 }
 
-func (m *Manager) decrypt(s string) (string, error) {
-	data, err := base64.StdEncoding.DecodeString(s)
-	if err != nil {
-		return "", fmt.Errorf("failed to decode base64 cipher: %s", err)
+// jsonValue returns strings as is, and other values (e.g. numbers) as JSON.
+func jsonValue(v json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(v, &s); err == nil {
+		return s
 	}
-	res, err := m.kms.Decrypt(&kms.DecryptInput{CiphertextBlob: data})
-	if err != nil {
-		return "", err
+	return string(v)
+}
+
+// expandNames returns the secrets for a reference that expands into several
+// environment variables, keyed by the name of the environment variable.
+func expandNames(ref *reference, secrets map[string]string) (map[string]string, error) {
+	var (
+		path, _, _ = strings.Cut(ref.path, "?")
+		out        = make(map[string]string, len(secrets))
+		sources    = make(map[string]string, len(secrets))
+	)
+	for _, name := range sortedKeys(secrets) {
+		if err := setExpanded(out, sources, ref.expandOptions.envName(path, name), name, secrets[name]); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// setExpanded sets the environment variable to the value from source, unless the name is empty. It
+// fails if another source maps to the same name, since one of the values would otherwise be dropped.
+func setExpanded(out, sources map[string]string, name, source, value string) error {
+	if name == "" {
+		return nil
 	}
-	return string(res.Plaintext), nil
+	if other, ok := sources[name]; ok && other != source {
+		return fmt.Errorf("%w: both %q and %q map to %s", ErrInvalidReference, other, source, name)
+	}
+	out[name], sources[name] = value, source
+	return nil
 }
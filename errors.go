@@ -0,0 +1,141 @@
+package environment
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+// Errors that can be used with errors.Is to determine why a secret could not be resolved.
+var (
+	// ErrNotFound is returned when the referenced secret, parameter or version does not exist.
+	ErrNotFound = errors.New("secret not found")
+	// ErrAccessDenied is returned when the caller is not allowed to read or decrypt the secret.
+	ErrAccessDenied = errors.New("access denied")
+	// ErrThrottled is returned when the request was throttled by AWS.
+	ErrThrottled = errors.New("request throttled")
+	// ErrInvalidReference is returned when the reference itself is malformed (e.g. an invalid KMS ciphertext),
+	// or does not match the secret (e.g. a multi-value reference to a secret that is not a JSON object).
+	ErrInvalidReference = errors.New("invalid reference")
+	// ErrMissingKey is returned when the key of a multi-value reference is not present in the secret.
+	ErrMissingKey = errors.New("missing key in multi-value secret")
+)
+
+// awsErrorCodes maps error codes returned by AWS to the error they are classified as.
+var awsErrorCodes = map[string]error{
+	"ResourceNotFoundException":  ErrNotFound,
+	"ParameterNotFound":          ErrNotFound,
+	"ParameterVersionNotFound":   ErrNotFound,
+	"NotFoundException":          ErrNotFound,
+	"AccessDeniedException":      ErrAccessDenied,
+	"DecryptionFailure":          ErrAccessDenied,
+	"InvalidParameterException":  ErrInvalidReference,
+	"ValidationException":        ErrInvalidReference,
+	"InvalidCiphertextException": ErrInvalidReference,
+	"IncorrectKeyException":      ErrInvalidReference,
+}
+
+// classifiedError wraps an error returned by AWS with the error it is classified as,
+// so that it matches both errors.Is(err, ErrNotFound) and errors.As(err, &awserr.Error).
+type classifiedError struct {
+	kind error
+	err  error
+}
+
+func (e *classifiedError) Error() string {
+	return fmt.Sprintf("%s: %s", e.kind, e.err)
+}
+
+func (e *classifiedError) Is(target error) bool {
+	return target == e.kind
+}
+
+func (e *classifiedError) Unwrap() error {
+	return e.err
+}
+
+// classify errors returned by AWS. Other errors, and error codes that are not
+// recognized, are returned as is.
+func classify(err error) error {
+	var e awserr.Error
+	if !errors.As(err, &e) {
+		return err
+	}
+	if kind, ok := awsErrorCodes[e.Code()]; ok {
+		return &classifiedError{kind: kind, err: err}
+	}
+	if request.IsErrorThrottle(err) {
+		return &classifiedError{kind: ErrThrottled, err: err}
+	}
+	return err
+}
+
+// ReferenceError is returned when the secret referenced by an environment variable could not be resolved.
+type ReferenceError struct {
+	// Name of the environment variable.
+	Name string
+	// Backend that was used to resolve the secret (i.e. "sm", "ssm" or "kms").
+	Backend string
+	// Path of the secret in the backend.
+	Path string
+	// Err is the underlying cause.
+	Err error
+}
+
+func (e *ReferenceError) Error() string {
+	return fmt.Sprintf("%s: %s://%s: %s", e.Name, e.Backend, e.Path, e.Err)
+}
+
+// Unwrap returns the underlying cause.
+func (e *ReferenceError) Unwrap() error {
+	return e.Err
+}
+
+// ReferenceErrors is returned when one or more secrets could not be resolved, and contains
+// an error for each failing environment variable in the order they appear in the environment.
+type ReferenceErrors []*ReferenceError
+
+func (e ReferenceErrors) Error() string {
+	if len(e) == 1 {
+		return "failed to resolve environment variable: " + e[0].Error()
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "failed to resolve %d environment variables:", len(e))
+	for _, err := range e {
+		b.WriteString("\n  - " + err.Error())
+	}
+	return b.String()
+}
+
+// Unwrap returns the errors for each environment variable.
+func (e ReferenceErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+	return errs
+}
+
+// Is reports whether any of the errors matches target.
+func (e ReferenceErrors) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first error that matches target.
+func (e ReferenceErrors) As(target interface{}) bool {
+	for _, err := range e {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
@@ -4,40 +4,61 @@ package fakes
 import (
 	"sync"
 
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/kms"
 	environment "github.com/telia-oss/aws-env"
 )
 
 type FakeKMSClient struct {
-	DecryptStub        func(*kms.DecryptInput) (*kms.DecryptOutput, error)
-	decryptMutex       sync.RWMutex
-	decryptArgsForCall []struct {
-		arg1 *kms.DecryptInput
+	DecryptWithContextStub        func(aws.Context, *kms.DecryptInput, ...request.Option) (*kms.DecryptOutput, error)
+	decryptWithContextMutex       sync.RWMutex
+	decryptWithContextArgsForCall []struct {
+		arg1 aws.Context
+		arg2 *kms.DecryptInput
+		arg3 []request.Option
 	}
-	decryptReturns struct {
+	decryptWithContextReturns struct {
 		result1 *kms.DecryptOutput
 		result2 error
 	}
-	decryptReturnsOnCall map[int]struct {
+	decryptWithContextReturnsOnCall map[int]struct {
 		result1 *kms.DecryptOutput
 		result2 error
 	}
+	EncryptWithContextStub        func(aws.Context, *kms.EncryptInput, ...request.Option) (*kms.EncryptOutput, error)
+	encryptWithContextMutex       sync.RWMutex
+	encryptWithContextArgsForCall []struct {
+		arg1 aws.Context
+		arg2 *kms.EncryptInput
+		arg3 []request.Option
+	}
+	encryptWithContextReturns struct {
+		result1 *kms.EncryptOutput
+		result2 error
+	}
+	encryptWithContextReturnsOnCall map[int]struct {
+		result1 *kms.EncryptOutput
+		result2 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *FakeKMSClient) Decrypt(arg1 *kms.DecryptInput) (*kms.DecryptOutput, error) {
-	fake.decryptMutex.Lock()
-	ret, specificReturn := fake.decryptReturnsOnCall[len(fake.decryptArgsForCall)]
-	fake.decryptArgsForCall = append(fake.decryptArgsForCall, struct {
-		arg1 *kms.DecryptInput
-	}{arg1})
-	stub := fake.DecryptStub
-	fakeReturns := fake.decryptReturns
-	fake.recordInvocation("Decrypt", []interface{}{arg1})
-	fake.decryptMutex.Unlock()
+func (fake *FakeKMSClient) DecryptWithContext(arg1 aws.Context, arg2 *kms.DecryptInput, arg3 ...request.Option) (*kms.DecryptOutput, error) {
+	fake.decryptWithContextMutex.Lock()
+	ret, specificReturn := fake.decryptWithContextReturnsOnCall[len(fake.decryptWithContextArgsForCall)]
+	fake.decryptWithContextArgsForCall = append(fake.decryptWithContextArgsForCall, struct {
+		arg1 aws.Context
+		arg2 *kms.DecryptInput
+		arg3 []request.Option
+	}{arg1, arg2, arg3})
+	stub := fake.DecryptWithContextStub
+	fakeReturns := fake.decryptWithContextReturns
+	fake.recordInvocation("DecryptWithContext", []interface{}{arg1, arg2, arg3})
+	fake.decryptWithContextMutex.Unlock()
 	if stub != nil {
-		return stub(arg1)
+		return stub(arg1, arg2, arg3...)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
@@ -45,56 +66,124 @@ func (fake *FakeKMSClient) Decrypt(arg1 *kms.DecryptInput) (*kms.DecryptOutput,
 	return fakeReturns.result1, fakeReturns.result2
 }
 
-func (fake *FakeKMSClient) DecryptCallCount() int {
-	fake.decryptMutex.RLock()
-	defer fake.decryptMutex.RUnlock()
-	return len(fake.decryptArgsForCall)
+func (fake *FakeKMSClient) DecryptWithContextCallCount() int {
+	fake.decryptWithContextMutex.RLock()
+	defer fake.decryptWithContextMutex.RUnlock()
+	return len(fake.decryptWithContextArgsForCall)
 }
 
-func (fake *FakeKMSClient) DecryptCalls(stub func(*kms.DecryptInput) (*kms.DecryptOutput, error)) {
-	fake.decryptMutex.Lock()
-	defer fake.decryptMutex.Unlock()
-	fake.DecryptStub = stub
+func (fake *FakeKMSClient) DecryptWithContextCalls(stub func(aws.Context, *kms.DecryptInput, ...request.Option) (*kms.DecryptOutput, error)) {
+	fake.decryptWithContextMutex.Lock()
+	defer fake.decryptWithContextMutex.Unlock()
+	fake.DecryptWithContextStub = stub
 }
 
-func (fake *FakeKMSClient) DecryptArgsForCall(i int) *kms.DecryptInput {
-	fake.decryptMutex.RLock()
-	defer fake.decryptMutex.RUnlock()
-	argsForCall := fake.decryptArgsForCall[i]
-	return argsForCall.arg1
+func (fake *FakeKMSClient) DecryptWithContextArgsForCall(i int) (aws.Context, *kms.DecryptInput, []request.Option) {
+	fake.decryptWithContextMutex.RLock()
+	defer fake.decryptWithContextMutex.RUnlock()
+	argsForCall := fake.decryptWithContextArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
 }
 
-func (fake *FakeKMSClient) DecryptReturns(result1 *kms.DecryptOutput, result2 error) {
-	fake.decryptMutex.Lock()
-	defer fake.decryptMutex.Unlock()
-	fake.DecryptStub = nil
-	fake.decryptReturns = struct {
+func (fake *FakeKMSClient) DecryptWithContextReturns(result1 *kms.DecryptOutput, result2 error) {
+	fake.decryptWithContextMutex.Lock()
+	defer fake.decryptWithContextMutex.Unlock()
+	fake.DecryptWithContextStub = nil
+	fake.decryptWithContextReturns = struct {
 		result1 *kms.DecryptOutput
 		result2 error
 	}{result1, result2}
 }
 
-func (fake *FakeKMSClient) DecryptReturnsOnCall(i int, result1 *kms.DecryptOutput, result2 error) {
-	fake.decryptMutex.Lock()
-	defer fake.decryptMutex.Unlock()
-	fake.DecryptStub = nil
-	if fake.decryptReturnsOnCall == nil {
-		fake.decryptReturnsOnCall = make(map[int]struct {
+func (fake *FakeKMSClient) DecryptWithContextReturnsOnCall(i int, result1 *kms.DecryptOutput, result2 error) {
+	fake.decryptWithContextMutex.Lock()
+	defer fake.decryptWithContextMutex.Unlock()
+	fake.DecryptWithContextStub = nil
+	if fake.decryptWithContextReturnsOnCall == nil {
+		fake.decryptWithContextReturnsOnCall = make(map[int]struct {
 			result1 *kms.DecryptOutput
 			result2 error
 		})
 	}
-	fake.decryptReturnsOnCall[i] = struct {
+	fake.decryptWithContextReturnsOnCall[i] = struct {
 		result1 *kms.DecryptOutput
 		result2 error
 	}{result1, result2}
 }
 
+func (fake *FakeKMSClient) EncryptWithContext(arg1 aws.Context, arg2 *kms.EncryptInput, arg3 ...request.Option) (*kms.EncryptOutput, error) {
+	fake.encryptWithContextMutex.Lock()
+	ret, specificReturn := fake.encryptWithContextReturnsOnCall[len(fake.encryptWithContextArgsForCall)]
+	fake.encryptWithContextArgsForCall = append(fake.encryptWithContextArgsForCall, struct {
+		arg1 aws.Context
+		arg2 *kms.EncryptInput
+		arg3 []request.Option
+	}{arg1, arg2, arg3})
+	stub := fake.EncryptWithContextStub
+	fakeReturns := fake.encryptWithContextReturns
+	fake.recordInvocation("EncryptWithContext", []interface{}{arg1, arg2, arg3})
+	fake.encryptWithContextMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3...)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeKMSClient) EncryptWithContextCallCount() int {
+	fake.encryptWithContextMutex.RLock()
+	defer fake.encryptWithContextMutex.RUnlock()
+	return len(fake.encryptWithContextArgsForCall)
+}
+
+func (fake *FakeKMSClient) EncryptWithContextCalls(stub func(aws.Context, *kms.EncryptInput, ...request.Option) (*kms.EncryptOutput, error)) {
+	fake.encryptWithContextMutex.Lock()
+	defer fake.encryptWithContextMutex.Unlock()
+	fake.EncryptWithContextStub = stub
+}
+
+func (fake *FakeKMSClient) EncryptWithContextArgsForCall(i int) (aws.Context, *kms.EncryptInput, []request.Option) {
+	fake.encryptWithContextMutex.RLock()
+	defer fake.encryptWithContextMutex.RUnlock()
+	argsForCall := fake.encryptWithContextArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeKMSClient) EncryptWithContextReturns(result1 *kms.EncryptOutput, result2 error) {
+	fake.encryptWithContextMutex.Lock()
+	defer fake.encryptWithContextMutex.Unlock()
+	fake.EncryptWithContextStub = nil
+	fake.encryptWithContextReturns = struct {
+		result1 *kms.EncryptOutput
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeKMSClient) EncryptWithContextReturnsOnCall(i int, result1 *kms.EncryptOutput, result2 error) {
+	fake.encryptWithContextMutex.Lock()
+	defer fake.encryptWithContextMutex.Unlock()
+	fake.EncryptWithContextStub = nil
+	if fake.encryptWithContextReturnsOnCall == nil {
+		fake.encryptWithContextReturnsOnCall = make(map[int]struct {
+			result1 *kms.EncryptOutput
+			result2 error
+		})
+	}
+	fake.encryptWithContextReturnsOnCall[i] = struct {
+		result1 *kms.EncryptOutput
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeKMSClient) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
-	fake.decryptMutex.RLock()
-	defer fake.decryptMutex.RUnlock()
+	fake.decryptWithContextMutex.RLock()
+	defer fake.decryptWithContextMutex.RUnlock()
+	fake.encryptWithContextMutex.RLock()
+	defer fake.encryptWithContextMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
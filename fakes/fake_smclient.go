@@ -4,40 +4,223 @@ package fakes
 import (
 	"sync"
 
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/secretsmanager"
 	environment "github.com/telia-oss/aws-env"
 )
 
 type FakeSMClient struct {
-	GetSecretValueStub        func(*secretsmanager.GetSecretValueInput) (*secretsmanager.GetSecretValueOutput, error)
-	getSecretValueMutex       sync.RWMutex
-	getSecretValueArgsForCall []struct {
-		arg1 *secretsmanager.GetSecretValueInput
+	BatchGetSecretValueWithContextStub        func(aws.Context, *secretsmanager.BatchGetSecretValueInput, ...request.Option) (*secretsmanager.BatchGetSecretValueOutput, error)
+	batchGetSecretValueWithContextMutex       sync.RWMutex
+	batchGetSecretValueWithContextArgsForCall []struct {
+		arg1 aws.Context
+		arg2 *secretsmanager.BatchGetSecretValueInput
+		arg3 []request.Option
 	}
-	getSecretValueReturns struct {
+	batchGetSecretValueWithContextReturns struct {
+		result1 *secretsmanager.BatchGetSecretValueOutput
+		result2 error
+	}
+	batchGetSecretValueWithContextReturnsOnCall map[int]struct {
+		result1 *secretsmanager.BatchGetSecretValueOutput
+		result2 error
+	}
+	CreateSecretWithContextStub        func(aws.Context, *secretsmanager.CreateSecretInput, ...request.Option) (*secretsmanager.CreateSecretOutput, error)
+	createSecretWithContextMutex       sync.RWMutex
+	createSecretWithContextArgsForCall []struct {
+		arg1 aws.Context
+		arg2 *secretsmanager.CreateSecretInput
+		arg3 []request.Option
+	}
+	createSecretWithContextReturns struct {
+		result1 *secretsmanager.CreateSecretOutput
+		result2 error
+	}
+	createSecretWithContextReturnsOnCall map[int]struct {
+		result1 *secretsmanager.CreateSecretOutput
+		result2 error
+	}
+	GetSecretValueWithContextStub        func(aws.Context, *secretsmanager.GetSecretValueInput, ...request.Option) (*secretsmanager.GetSecretValueOutput, error)
+	getSecretValueWithContextMutex       sync.RWMutex
+	getSecretValueWithContextArgsForCall []struct {
+		arg1 aws.Context
+		arg2 *secretsmanager.GetSecretValueInput
+		arg3 []request.Option
+	}
+	getSecretValueWithContextReturns struct {
 		result1 *secretsmanager.GetSecretValueOutput
 		result2 error
 	}
-	getSecretValueReturnsOnCall map[int]struct {
+	getSecretValueWithContextReturnsOnCall map[int]struct {
 		result1 *secretsmanager.GetSecretValueOutput
 		result2 error
 	}
+	PutSecretValueWithContextStub        func(aws.Context, *secretsmanager.PutSecretValueInput, ...request.Option) (*secretsmanager.PutSecretValueOutput, error)
+	putSecretValueWithContextMutex       sync.RWMutex
+	putSecretValueWithContextArgsForCall []struct {
+		arg1 aws.Context
+		arg2 *secretsmanager.PutSecretValueInput
+		arg3 []request.Option
+	}
+	putSecretValueWithContextReturns struct {
+		result1 *secretsmanager.PutSecretValueOutput
+		result2 error
+	}
+	putSecretValueWithContextReturnsOnCall map[int]struct {
+		result1 *secretsmanager.PutSecretValueOutput
+		result2 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *FakeSMClient) GetSecretValue(arg1 *secretsmanager.GetSecretValueInput) (*secretsmanager.GetSecretValueOutput, error) {
-	fake.getSecretValueMutex.Lock()
-	ret, specificReturn := fake.getSecretValueReturnsOnCall[len(fake.getSecretValueArgsForCall)]
-	fake.getSecretValueArgsForCall = append(fake.getSecretValueArgsForCall, struct {
-		arg1 *secretsmanager.GetSecretValueInput
-	}{arg1})
-	stub := fake.GetSecretValueStub
-	fakeReturns := fake.getSecretValueReturns
-	fake.recordInvocation("GetSecretValue", []interface{}{arg1})
-	fake.getSecretValueMutex.Unlock()
+func (fake *FakeSMClient) BatchGetSecretValueWithContext(arg1 aws.Context, arg2 *secretsmanager.BatchGetSecretValueInput, arg3 ...request.Option) (*secretsmanager.BatchGetSecretValueOutput, error) {
+	fake.batchGetSecretValueWithContextMutex.Lock()
+	ret, specificReturn := fake.batchGetSecretValueWithContextReturnsOnCall[len(fake.batchGetSecretValueWithContextArgsForCall)]
+	fake.batchGetSecretValueWithContextArgsForCall = append(fake.batchGetSecretValueWithContextArgsForCall, struct {
+		arg1 aws.Context
+		arg2 *secretsmanager.BatchGetSecretValueInput
+		arg3 []request.Option
+	}{arg1, arg2, arg3})
+	stub := fake.BatchGetSecretValueWithContextStub
+	fakeReturns := fake.batchGetSecretValueWithContextReturns
+	fake.recordInvocation("BatchGetSecretValueWithContext", []interface{}{arg1, arg2, arg3})
+	fake.batchGetSecretValueWithContextMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3...)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeSMClient) BatchGetSecretValueWithContextCallCount() int {
+	fake.batchGetSecretValueWithContextMutex.RLock()
+	defer fake.batchGetSecretValueWithContextMutex.RUnlock()
+	return len(fake.batchGetSecretValueWithContextArgsForCall)
+}
+
+func (fake *FakeSMClient) BatchGetSecretValueWithContextCalls(stub func(aws.Context, *secretsmanager.BatchGetSecretValueInput, ...request.Option) (*secretsmanager.BatchGetSecretValueOutput, error)) {
+	fake.batchGetSecretValueWithContextMutex.Lock()
+	defer fake.batchGetSecretValueWithContextMutex.Unlock()
+	fake.BatchGetSecretValueWithContextStub = stub
+}
+
+func (fake *FakeSMClient) BatchGetSecretValueWithContextArgsForCall(i int) (aws.Context, *secretsmanager.BatchGetSecretValueInput, []request.Option) {
+	fake.batchGetSecretValueWithContextMutex.RLock()
+	defer fake.batchGetSecretValueWithContextMutex.RUnlock()
+	argsForCall := fake.batchGetSecretValueWithContextArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeSMClient) BatchGetSecretValueWithContextReturns(result1 *secretsmanager.BatchGetSecretValueOutput, result2 error) {
+	fake.batchGetSecretValueWithContextMutex.Lock()
+	defer fake.batchGetSecretValueWithContextMutex.Unlock()
+	fake.BatchGetSecretValueWithContextStub = nil
+	fake.batchGetSecretValueWithContextReturns = struct {
+		result1 *secretsmanager.BatchGetSecretValueOutput
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeSMClient) BatchGetSecretValueWithContextReturnsOnCall(i int, result1 *secretsmanager.BatchGetSecretValueOutput, result2 error) {
+	fake.batchGetSecretValueWithContextMutex.Lock()
+	defer fake.batchGetSecretValueWithContextMutex.Unlock()
+	fake.BatchGetSecretValueWithContextStub = nil
+	if fake.batchGetSecretValueWithContextReturnsOnCall == nil {
+		fake.batchGetSecretValueWithContextReturnsOnCall = make(map[int]struct {
+			result1 *secretsmanager.BatchGetSecretValueOutput
+			result2 error
+		})
+	}
+	fake.batchGetSecretValueWithContextReturnsOnCall[i] = struct {
+		result1 *secretsmanager.BatchGetSecretValueOutput
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeSMClient) CreateSecretWithContext(arg1 aws.Context, arg2 *secretsmanager.CreateSecretInput, arg3 ...request.Option) (*secretsmanager.CreateSecretOutput, error) {
+	fake.createSecretWithContextMutex.Lock()
+	ret, specificReturn := fake.createSecretWithContextReturnsOnCall[len(fake.createSecretWithContextArgsForCall)]
+	fake.createSecretWithContextArgsForCall = append(fake.createSecretWithContextArgsForCall, struct {
+		arg1 aws.Context
+		arg2 *secretsmanager.CreateSecretInput
+		arg3 []request.Option
+	}{arg1, arg2, arg3})
+	stub := fake.CreateSecretWithContextStub
+	fakeReturns := fake.createSecretWithContextReturns
+	fake.recordInvocation("CreateSecretWithContext", []interface{}{arg1, arg2, arg3})
+	fake.createSecretWithContextMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3...)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeSMClient) CreateSecretWithContextCallCount() int {
+	fake.createSecretWithContextMutex.RLock()
+	defer fake.createSecretWithContextMutex.RUnlock()
+	return len(fake.createSecretWithContextArgsForCall)
+}
+
+func (fake *FakeSMClient) CreateSecretWithContextCalls(stub func(aws.Context, *secretsmanager.CreateSecretInput, ...request.Option) (*secretsmanager.CreateSecretOutput, error)) {
+	fake.createSecretWithContextMutex.Lock()
+	defer fake.createSecretWithContextMutex.Unlock()
+	fake.CreateSecretWithContextStub = stub
+}
+
+func (fake *FakeSMClient) CreateSecretWithContextArgsForCall(i int) (aws.Context, *secretsmanager.CreateSecretInput, []request.Option) {
+	fake.createSecretWithContextMutex.RLock()
+	defer fake.createSecretWithContextMutex.RUnlock()
+	argsForCall := fake.createSecretWithContextArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeSMClient) CreateSecretWithContextReturns(result1 *secretsmanager.CreateSecretOutput, result2 error) {
+	fake.createSecretWithContextMutex.Lock()
+	defer fake.createSecretWithContextMutex.Unlock()
+	fake.CreateSecretWithContextStub = nil
+	fake.createSecretWithContextReturns = struct {
+		result1 *secretsmanager.CreateSecretOutput
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeSMClient) CreateSecretWithContextReturnsOnCall(i int, result1 *secretsmanager.CreateSecretOutput, result2 error) {
+	fake.createSecretWithContextMutex.Lock()
+	defer fake.createSecretWithContextMutex.Unlock()
+	fake.CreateSecretWithContextStub = nil
+	if fake.createSecretWithContextReturnsOnCall == nil {
+		fake.createSecretWithContextReturnsOnCall = make(map[int]struct {
+			result1 *secretsmanager.CreateSecretOutput
+			result2 error
+		})
+	}
+	fake.createSecretWithContextReturnsOnCall[i] = struct {
+		result1 *secretsmanager.CreateSecretOutput
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeSMClient) GetSecretValueWithContext(arg1 aws.Context, arg2 *secretsmanager.GetSecretValueInput, arg3 ...request.Option) (*secretsmanager.GetSecretValueOutput, error) {
+	fake.getSecretValueWithContextMutex.Lock()
+	ret, specificReturn := fake.getSecretValueWithContextReturnsOnCall[len(fake.getSecretValueWithContextArgsForCall)]
+	fake.getSecretValueWithContextArgsForCall = append(fake.getSecretValueWithContextArgsForCall, struct {
+		arg1 aws.Context
+		arg2 *secretsmanager.GetSecretValueInput
+		arg3 []request.Option
+	}{arg1, arg2, arg3})
+	stub := fake.GetSecretValueWithContextStub
+	fakeReturns := fake.getSecretValueWithContextReturns
+	fake.recordInvocation("GetSecretValueWithContext", []interface{}{arg1, arg2, arg3})
+	fake.getSecretValueWithContextMutex.Unlock()
 	if stub != nil {
-		return stub(arg1)
+		return stub(arg1, arg2, arg3...)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
@@ -45,56 +228,128 @@ func (fake *FakeSMClient) GetSecretValue(arg1 *secretsmanager.GetSecretValueInpu
 	return fakeReturns.result1, fakeReturns.result2
 }
 
-func (fake *FakeSMClient) GetSecretValueCallCount() int {
-	fake.getSecretValueMutex.RLock()
-	defer fake.getSecretValueMutex.RUnlock()
-	return len(fake.getSecretValueArgsForCall)
+func (fake *FakeSMClient) GetSecretValueWithContextCallCount() int {
+	fake.getSecretValueWithContextMutex.RLock()
+	defer fake.getSecretValueWithContextMutex.RUnlock()
+	return len(fake.getSecretValueWithContextArgsForCall)
 }
 
-func (fake *FakeSMClient) GetSecretValueCalls(stub func(*secretsmanager.GetSecretValueInput) (*secretsmanager.GetSecretValueOutput, error)) {
-	fake.getSecretValueMutex.Lock()
-	defer fake.getSecretValueMutex.Unlock()
-	fake.GetSecretValueStub = stub
+func (fake *FakeSMClient) GetSecretValueWithContextCalls(stub func(aws.Context, *secretsmanager.GetSecretValueInput, ...request.Option) (*secretsmanager.GetSecretValueOutput, error)) {
+	fake.getSecretValueWithContextMutex.Lock()
+	defer fake.getSecretValueWithContextMutex.Unlock()
+	fake.GetSecretValueWithContextStub = stub
 }
 
-func (fake *FakeSMClient) GetSecretValueArgsForCall(i int) *secretsmanager.GetSecretValueInput {
-	fake.getSecretValueMutex.RLock()
-	defer fake.getSecretValueMutex.RUnlock()
-	argsForCall := fake.getSecretValueArgsForCall[i]
-	return argsForCall.arg1
+func (fake *FakeSMClient) GetSecretValueWithContextArgsForCall(i int) (aws.Context, *secretsmanager.GetSecretValueInput, []request.Option) {
+	fake.getSecretValueWithContextMutex.RLock()
+	defer fake.getSecretValueWithContextMutex.RUnlock()
+	argsForCall := fake.getSecretValueWithContextArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
 }
 
-func (fake *FakeSMClient) GetSecretValueReturns(result1 *secretsmanager.GetSecretValueOutput, result2 error) {
-	fake.getSecretValueMutex.Lock()
-	defer fake.getSecretValueMutex.Unlock()
-	fake.GetSecretValueStub = nil
-	fake.getSecretValueReturns = struct {
+func (fake *FakeSMClient) GetSecretValueWithContextReturns(result1 *secretsmanager.GetSecretValueOutput, result2 error) {
+	fake.getSecretValueWithContextMutex.Lock()
+	defer fake.getSecretValueWithContextMutex.Unlock()
+	fake.GetSecretValueWithContextStub = nil
+	fake.getSecretValueWithContextReturns = struct {
 		result1 *secretsmanager.GetSecretValueOutput
 		result2 error
 	}{result1, result2}
 }
 
-func (fake *FakeSMClient) GetSecretValueReturnsOnCall(i int, result1 *secretsmanager.GetSecretValueOutput, result2 error) {
-	fake.getSecretValueMutex.Lock()
-	defer fake.getSecretValueMutex.Unlock()
-	fake.GetSecretValueStub = nil
-	if fake.getSecretValueReturnsOnCall == nil {
-		fake.getSecretValueReturnsOnCall = make(map[int]struct {
+func (fake *FakeSMClient) GetSecretValueWithContextReturnsOnCall(i int, result1 *secretsmanager.GetSecretValueOutput, result2 error) {
+	fake.getSecretValueWithContextMutex.Lock()
+	defer fake.getSecretValueWithContextMutex.Unlock()
+	fake.GetSecretValueWithContextStub = nil
+	if fake.getSecretValueWithContextReturnsOnCall == nil {
+		fake.getSecretValueWithContextReturnsOnCall = make(map[int]struct {
 			result1 *secretsmanager.GetSecretValueOutput
 			result2 error
 		})
 	}
-	fake.getSecretValueReturnsOnCall[i] = struct {
+	fake.getSecretValueWithContextReturnsOnCall[i] = struct {
 		result1 *secretsmanager.GetSecretValueOutput
 		result2 error
 	}{result1, result2}
 }
 
+func (fake *FakeSMClient) PutSecretValueWithContext(arg1 aws.Context, arg2 *secretsmanager.PutSecretValueInput, arg3 ...request.Option) (*secretsmanager.PutSecretValueOutput, error) {
+	fake.putSecretValueWithContextMutex.Lock()
+	ret, specificReturn := fake.putSecretValueWithContextReturnsOnCall[len(fake.putSecretValueWithContextArgsForCall)]
+	fake.putSecretValueWithContextArgsForCall = append(fake.putSecretValueWithContextArgsForCall, struct {
+		arg1 aws.Context
+		arg2 *secretsmanager.PutSecretValueInput
+		arg3 []request.Option
+	}{arg1, arg2, arg3})
+	stub := fake.PutSecretValueWithContextStub
+	fakeReturns := fake.putSecretValueWithContextReturns
+	fake.recordInvocation("PutSecretValueWithContext", []interface{}{arg1, arg2, arg3})
+	fake.putSecretValueWithContextMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3...)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeSMClient) PutSecretValueWithContextCallCount() int {
+	fake.putSecretValueWithContextMutex.RLock()
+	defer fake.putSecretValueWithContextMutex.RUnlock()
+	return len(fake.putSecretValueWithContextArgsForCall)
+}
+
+func (fake *FakeSMClient) PutSecretValueWithContextCalls(stub func(aws.Context, *secretsmanager.PutSecretValueInput, ...request.Option) (*secretsmanager.PutSecretValueOutput, error)) {
+	fake.putSecretValueWithContextMutex.Lock()
+	defer fake.putSecretValueWithContextMutex.Unlock()
+	fake.PutSecretValueWithContextStub = stub
+}
+
+func (fake *FakeSMClient) PutSecretValueWithContextArgsForCall(i int) (aws.Context, *secretsmanager.PutSecretValueInput, []request.Option) {
+	fake.putSecretValueWithContextMutex.RLock()
+	defer fake.putSecretValueWithContextMutex.RUnlock()
+	argsForCall := fake.putSecretValueWithContextArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeSMClient) PutSecretValueWithContextReturns(result1 *secretsmanager.PutSecretValueOutput, result2 error) {
+	fake.putSecretValueWithContextMutex.Lock()
+	defer fake.putSecretValueWithContextMutex.Unlock()
+	fake.PutSecretValueWithContextStub = nil
+	fake.putSecretValueWithContextReturns = struct {
+		result1 *secretsmanager.PutSecretValueOutput
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeSMClient) PutSecretValueWithContextReturnsOnCall(i int, result1 *secretsmanager.PutSecretValueOutput, result2 error) {
+	fake.putSecretValueWithContextMutex.Lock()
+	defer fake.putSecretValueWithContextMutex.Unlock()
+	fake.PutSecretValueWithContextStub = nil
+	if fake.putSecretValueWithContextReturnsOnCall == nil {
+		fake.putSecretValueWithContextReturnsOnCall = make(map[int]struct {
+			result1 *secretsmanager.PutSecretValueOutput
+			result2 error
+		})
+	}
+	fake.putSecretValueWithContextReturnsOnCall[i] = struct {
+		result1 *secretsmanager.PutSecretValueOutput
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeSMClient) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
-	fake.getSecretValueMutex.RLock()
-	defer fake.getSecretValueMutex.RUnlock()
+	fake.batchGetSecretValueWithContextMutex.RLock()
+	defer fake.batchGetSecretValueWithContextMutex.RUnlock()
+	fake.createSecretWithContextMutex.RLock()
+	defer fake.createSecretWithContextMutex.RUnlock()
+	fake.getSecretValueWithContextMutex.RLock()
+	defer fake.getSecretValueWithContextMutex.RUnlock()
+	fake.putSecretValueWithContextMutex.RLock()
+	defer fake.putSecretValueWithContextMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
@@ -4,40 +4,172 @@ package fakes
 import (
 	"sync"
 
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/ssm"
 	environment "github.com/telia-oss/aws-env"
 )
 
 type FakeSSMClient struct {
-	GetParameterStub        func(*ssm.GetParameterInput) (*ssm.GetParameterOutput, error)
-	getParameterMutex       sync.RWMutex
-	getParameterArgsForCall []struct {
-		arg1 *ssm.GetParameterInput
+	DescribeParametersWithContextStub        func(aws.Context, *ssm.DescribeParametersInput, ...request.Option) (*ssm.DescribeParametersOutput, error)
+	describeParametersWithContextMutex       sync.RWMutex
+	describeParametersWithContextArgsForCall []struct {
+		arg1 aws.Context
+		arg2 *ssm.DescribeParametersInput
+		arg3 []request.Option
 	}
-	getParameterReturns struct {
+	describeParametersWithContextReturns struct {
+		result1 *ssm.DescribeParametersOutput
+		result2 error
+	}
+	describeParametersWithContextReturnsOnCall map[int]struct {
+		result1 *ssm.DescribeParametersOutput
+		result2 error
+	}
+	GetParameterWithContextStub        func(aws.Context, *ssm.GetParameterInput, ...request.Option) (*ssm.GetParameterOutput, error)
+	getParameterWithContextMutex       sync.RWMutex
+	getParameterWithContextArgsForCall []struct {
+		arg1 aws.Context
+		arg2 *ssm.GetParameterInput
+		arg3 []request.Option
+	}
+	getParameterWithContextReturns struct {
 		result1 *ssm.GetParameterOutput
 		result2 error
 	}
-	getParameterReturnsOnCall map[int]struct {
+	getParameterWithContextReturnsOnCall map[int]struct {
 		result1 *ssm.GetParameterOutput
 		result2 error
 	}
+	GetParametersByPathWithContextStub        func(aws.Context, *ssm.GetParametersByPathInput, ...request.Option) (*ssm.GetParametersByPathOutput, error)
+	getParametersByPathWithContextMutex       sync.RWMutex
+	getParametersByPathWithContextArgsForCall []struct {
+		arg1 aws.Context
+		arg2 *ssm.GetParametersByPathInput
+		arg3 []request.Option
+	}
+	getParametersByPathWithContextReturns struct {
+		result1 *ssm.GetParametersByPathOutput
+		result2 error
+	}
+	getParametersByPathWithContextReturnsOnCall map[int]struct {
+		result1 *ssm.GetParametersByPathOutput
+		result2 error
+	}
+	GetParametersWithContextStub        func(aws.Context, *ssm.GetParametersInput, ...request.Option) (*ssm.GetParametersOutput, error)
+	getParametersWithContextMutex       sync.RWMutex
+	getParametersWithContextArgsForCall []struct {
+		arg1 aws.Context
+		arg2 *ssm.GetParametersInput
+		arg3 []request.Option
+	}
+	getParametersWithContextReturns struct {
+		result1 *ssm.GetParametersOutput
+		result2 error
+	}
+	getParametersWithContextReturnsOnCall map[int]struct {
+		result1 *ssm.GetParametersOutput
+		result2 error
+	}
+	PutParameterWithContextStub        func(aws.Context, *ssm.PutParameterInput, ...request.Option) (*ssm.PutParameterOutput, error)
+	putParameterWithContextMutex       sync.RWMutex
+	putParameterWithContextArgsForCall []struct {
+		arg1 aws.Context
+		arg2 *ssm.PutParameterInput
+		arg3 []request.Option
+	}
+	putParameterWithContextReturns struct {
+		result1 *ssm.PutParameterOutput
+		result2 error
+	}
+	putParameterWithContextReturnsOnCall map[int]struct {
+		result1 *ssm.PutParameterOutput
+		result2 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *FakeSSMClient) GetParameter(arg1 *ssm.GetParameterInput) (*ssm.GetParameterOutput, error) {
-	fake.getParameterMutex.Lock()
-	ret, specificReturn := fake.getParameterReturnsOnCall[len(fake.getParameterArgsForCall)]
-	fake.getParameterArgsForCall = append(fake.getParameterArgsForCall, struct {
-		arg1 *ssm.GetParameterInput
-	}{arg1})
-	stub := fake.GetParameterStub
-	fakeReturns := fake.getParameterReturns
-	fake.recordInvocation("GetParameter", []interface{}{arg1})
-	fake.getParameterMutex.Unlock()
+func (fake *FakeSSMClient) DescribeParametersWithContext(arg1 aws.Context, arg2 *ssm.DescribeParametersInput, arg3 ...request.Option) (*ssm.DescribeParametersOutput, error) {
+	fake.describeParametersWithContextMutex.Lock()
+	ret, specificReturn := fake.describeParametersWithContextReturnsOnCall[len(fake.describeParametersWithContextArgsForCall)]
+	fake.describeParametersWithContextArgsForCall = append(fake.describeParametersWithContextArgsForCall, struct {
+		arg1 aws.Context
+		arg2 *ssm.DescribeParametersInput
+		arg3 []request.Option
+	}{arg1, arg2, arg3})
+	stub := fake.DescribeParametersWithContextStub
+	fakeReturns := fake.describeParametersWithContextReturns
+	fake.recordInvocation("DescribeParametersWithContext", []interface{}{arg1, arg2, arg3})
+	fake.describeParametersWithContextMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3...)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeSSMClient) DescribeParametersWithContextCallCount() int {
+	fake.describeParametersWithContextMutex.RLock()
+	defer fake.describeParametersWithContextMutex.RUnlock()
+	return len(fake.describeParametersWithContextArgsForCall)
+}
+
+func (fake *FakeSSMClient) DescribeParametersWithContextCalls(stub func(aws.Context, *ssm.DescribeParametersInput, ...request.Option) (*ssm.DescribeParametersOutput, error)) {
+	fake.describeParametersWithContextMutex.Lock()
+	defer fake.describeParametersWithContextMutex.Unlock()
+	fake.DescribeParametersWithContextStub = stub
+}
+
+func (fake *FakeSSMClient) DescribeParametersWithContextArgsForCall(i int) (aws.Context, *ssm.DescribeParametersInput, []request.Option) {
+	fake.describeParametersWithContextMutex.RLock()
+	defer fake.describeParametersWithContextMutex.RUnlock()
+	argsForCall := fake.describeParametersWithContextArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeSSMClient) DescribeParametersWithContextReturns(result1 *ssm.DescribeParametersOutput, result2 error) {
+	fake.describeParametersWithContextMutex.Lock()
+	defer fake.describeParametersWithContextMutex.Unlock()
+	fake.DescribeParametersWithContextStub = nil
+	fake.describeParametersWithContextReturns = struct {
+		result1 *ssm.DescribeParametersOutput
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeSSMClient) DescribeParametersWithContextReturnsOnCall(i int, result1 *ssm.DescribeParametersOutput, result2 error) {
+	fake.describeParametersWithContextMutex.Lock()
+	defer fake.describeParametersWithContextMutex.Unlock()
+	fake.DescribeParametersWithContextStub = nil
+	if fake.describeParametersWithContextReturnsOnCall == nil {
+		fake.describeParametersWithContextReturnsOnCall = make(map[int]struct {
+			result1 *ssm.DescribeParametersOutput
+			result2 error
+		})
+	}
+	fake.describeParametersWithContextReturnsOnCall[i] = struct {
+		result1 *ssm.DescribeParametersOutput
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeSSMClient) GetParameterWithContext(arg1 aws.Context, arg2 *ssm.GetParameterInput, arg3 ...request.Option) (*ssm.GetParameterOutput, error) {
+	fake.getParameterWithContextMutex.Lock()
+	ret, specificReturn := fake.getParameterWithContextReturnsOnCall[len(fake.getParameterWithContextArgsForCall)]
+	fake.getParameterWithContextArgsForCall = append(fake.getParameterWithContextArgsForCall, struct {
+		arg1 aws.Context
+		arg2 *ssm.GetParameterInput
+		arg3 []request.Option
+	}{arg1, arg2, arg3})
+	stub := fake.GetParameterWithContextStub
+	fakeReturns := fake.getParameterWithContextReturns
+	fake.recordInvocation("GetParameterWithContext", []interface{}{arg1, arg2, arg3})
+	fake.getParameterWithContextMutex.Unlock()
 	if stub != nil {
-		return stub(arg1)
+		return stub(arg1, arg2, arg3...)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
@@ -45,56 +177,262 @@ func (fake *FakeSSMClient) GetParameter(arg1 *ssm.GetParameterInput) (*ssm.GetPa
 	return fakeReturns.result1, fakeReturns.result2
 }
 
-func (fake *FakeSSMClient) GetParameterCallCount() int {
-	fake.getParameterMutex.RLock()
-	defer fake.getParameterMutex.RUnlock()
-	return len(fake.getParameterArgsForCall)
+func (fake *FakeSSMClient) GetParameterWithContextCallCount() int {
+	fake.getParameterWithContextMutex.RLock()
+	defer fake.getParameterWithContextMutex.RUnlock()
+	return len(fake.getParameterWithContextArgsForCall)
 }
 
-func (fake *FakeSSMClient) GetParameterCalls(stub func(*ssm.GetParameterInput) (*ssm.GetParameterOutput, error)) {
-	fake.getParameterMutex.Lock()
-	defer fake.getParameterMutex.Unlock()
-	fake.GetParameterStub = stub
+func (fake *FakeSSMClient) GetParameterWithContextCalls(stub func(aws.Context, *ssm.GetParameterInput, ...request.Option) (*ssm.GetParameterOutput, error)) {
+	fake.getParameterWithContextMutex.Lock()
+	defer fake.getParameterWithContextMutex.Unlock()
+	fake.GetParameterWithContextStub = stub
 }
 
-func (fake *FakeSSMClient) GetParameterArgsForCall(i int) *ssm.GetParameterInput {
-	fake.getParameterMutex.RLock()
-	defer fake.getParameterMutex.RUnlock()
-	argsForCall := fake.getParameterArgsForCall[i]
-	return argsForCall.arg1
+func (fake *FakeSSMClient) GetParameterWithContextArgsForCall(i int) (aws.Context, *ssm.GetParameterInput, []request.Option) {
+	fake.getParameterWithContextMutex.RLock()
+	defer fake.getParameterWithContextMutex.RUnlock()
+	argsForCall := fake.getParameterWithContextArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
 }
 
-func (fake *FakeSSMClient) GetParameterReturns(result1 *ssm.GetParameterOutput, result2 error) {
-	fake.getParameterMutex.Lock()
-	defer fake.getParameterMutex.Unlock()
-	fake.GetParameterStub = nil
-	fake.getParameterReturns = struct {
+func (fake *FakeSSMClient) GetParameterWithContextReturns(result1 *ssm.GetParameterOutput, result2 error) {
+	fake.getParameterWithContextMutex.Lock()
+	defer fake.getParameterWithContextMutex.Unlock()
+	fake.GetParameterWithContextStub = nil
+	fake.getParameterWithContextReturns = struct {
 		result1 *ssm.GetParameterOutput
 		result2 error
 	}{result1, result2}
 }
 
-func (fake *FakeSSMClient) GetParameterReturnsOnCall(i int, result1 *ssm.GetParameterOutput, result2 error) {
-	fake.getParameterMutex.Lock()
-	defer fake.getParameterMutex.Unlock()
-	fake.GetParameterStub = nil
-	if fake.getParameterReturnsOnCall == nil {
-		fake.getParameterReturnsOnCall = make(map[int]struct {
+func (fake *FakeSSMClient) GetParameterWithContextReturnsOnCall(i int, result1 *ssm.GetParameterOutput, result2 error) {
+	fake.getParameterWithContextMutex.Lock()
+	defer fake.getParameterWithContextMutex.Unlock()
+	fake.GetParameterWithContextStub = nil
+	if fake.getParameterWithContextReturnsOnCall == nil {
+		fake.getParameterWithContextReturnsOnCall = make(map[int]struct {
 			result1 *ssm.GetParameterOutput
 			result2 error
 		})
 	}
-	fake.getParameterReturnsOnCall[i] = struct {
+	fake.getParameterWithContextReturnsOnCall[i] = struct {
 		result1 *ssm.GetParameterOutput
 		result2 error
 	}{result1, result2}
 }
 
+func (fake *FakeSSMClient) GetParametersByPathWithContext(arg1 aws.Context, arg2 *ssm.GetParametersByPathInput, arg3 ...request.Option) (*ssm.GetParametersByPathOutput, error) {
+	fake.getParametersByPathWithContextMutex.Lock()
+	ret, specificReturn := fake.getParametersByPathWithContextReturnsOnCall[len(fake.getParametersByPathWithContextArgsForCall)]
+	fake.getParametersByPathWithContextArgsForCall = append(fake.getParametersByPathWithContextArgsForCall, struct {
+		arg1 aws.Context
+		arg2 *ssm.GetParametersByPathInput
+		arg3 []request.Option
+	}{arg1, arg2, arg3})
+	stub := fake.GetParametersByPathWithContextStub
+	fakeReturns := fake.getParametersByPathWithContextReturns
+	fake.recordInvocation("GetParametersByPathWithContext", []interface{}{arg1, arg2, arg3})
+	fake.getParametersByPathWithContextMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3...)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeSSMClient) GetParametersByPathWithContextCallCount() int {
+	fake.getParametersByPathWithContextMutex.RLock()
+	defer fake.getParametersByPathWithContextMutex.RUnlock()
+	return len(fake.getParametersByPathWithContextArgsForCall)
+}
+
+func (fake *FakeSSMClient) GetParametersByPathWithContextCalls(stub func(aws.Context, *ssm.GetParametersByPathInput, ...request.Option) (*ssm.GetParametersByPathOutput, error)) {
+	fake.getParametersByPathWithContextMutex.Lock()
+	defer fake.getParametersByPathWithContextMutex.Unlock()
+	fake.GetParametersByPathWithContextStub = stub
+}
+
+func (fake *FakeSSMClient) GetParametersByPathWithContextArgsForCall(i int) (aws.Context, *ssm.GetParametersByPathInput, []request.Option) {
+	fake.getParametersByPathWithContextMutex.RLock()
+	defer fake.getParametersByPathWithContextMutex.RUnlock()
+	argsForCall := fake.getParametersByPathWithContextArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeSSMClient) GetParametersByPathWithContextReturns(result1 *ssm.GetParametersByPathOutput, result2 error) {
+	fake.getParametersByPathWithContextMutex.Lock()
+	defer fake.getParametersByPathWithContextMutex.Unlock()
+	fake.GetParametersByPathWithContextStub = nil
+	fake.getParametersByPathWithContextReturns = struct {
+		result1 *ssm.GetParametersByPathOutput
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeSSMClient) GetParametersByPathWithContextReturnsOnCall(i int, result1 *ssm.GetParametersByPathOutput, result2 error) {
+	fake.getParametersByPathWithContextMutex.Lock()
+	defer fake.getParametersByPathWithContextMutex.Unlock()
+	fake.GetParametersByPathWithContextStub = nil
+	if fake.getParametersByPathWithContextReturnsOnCall == nil {
+		fake.getParametersByPathWithContextReturnsOnCall = make(map[int]struct {
+			result1 *ssm.GetParametersByPathOutput
+			result2 error
+		})
+	}
+	fake.getParametersByPathWithContextReturnsOnCall[i] = struct {
+		result1 *ssm.GetParametersByPathOutput
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeSSMClient) GetParametersWithContext(arg1 aws.Context, arg2 *ssm.GetParametersInput, arg3 ...request.Option) (*ssm.GetParametersOutput, error) {
+	fake.getParametersWithContextMutex.Lock()
+	ret, specificReturn := fake.getParametersWithContextReturnsOnCall[len(fake.getParametersWithContextArgsForCall)]
+	fake.getParametersWithContextArgsForCall = append(fake.getParametersWithContextArgsForCall, struct {
+		arg1 aws.Context
+		arg2 *ssm.GetParametersInput
+		arg3 []request.Option
+	}{arg1, arg2, arg3})
+	stub := fake.GetParametersWithContextStub
+	fakeReturns := fake.getParametersWithContextReturns
+	fake.recordInvocation("GetParametersWithContext", []interface{}{arg1, arg2, arg3})
+	fake.getParametersWithContextMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3...)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeSSMClient) GetParametersWithContextCallCount() int {
+	fake.getParametersWithContextMutex.RLock()
+	defer fake.getParametersWithContextMutex.RUnlock()
+	return len(fake.getParametersWithContextArgsForCall)
+}
+
+func (fake *FakeSSMClient) GetParametersWithContextCalls(stub func(aws.Context, *ssm.GetParametersInput, ...request.Option) (*ssm.GetParametersOutput, error)) {
+	fake.getParametersWithContextMutex.Lock()
+	defer fake.getParametersWithContextMutex.Unlock()
+	fake.GetParametersWithContextStub = stub
+}
+
+func (fake *FakeSSMClient) GetParametersWithContextArgsForCall(i int) (aws.Context, *ssm.GetParametersInput, []request.Option) {
+	fake.getParametersWithContextMutex.RLock()
+	defer fake.getParametersWithContextMutex.RUnlock()
+	argsForCall := fake.getParametersWithContextArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeSSMClient) GetParametersWithContextReturns(result1 *ssm.GetParametersOutput, result2 error) {
+	fake.getParametersWithContextMutex.Lock()
+	defer fake.getParametersWithContextMutex.Unlock()
+	fake.GetParametersWithContextStub = nil
+	fake.getParametersWithContextReturns = struct {
+		result1 *ssm.GetParametersOutput
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeSSMClient) GetParametersWithContextReturnsOnCall(i int, result1 *ssm.GetParametersOutput, result2 error) {
+	fake.getParametersWithContextMutex.Lock()
+	defer fake.getParametersWithContextMutex.Unlock()
+	fake.GetParametersWithContextStub = nil
+	if fake.getParametersWithContextReturnsOnCall == nil {
+		fake.getParametersWithContextReturnsOnCall = make(map[int]struct {
+			result1 *ssm.GetParametersOutput
+			result2 error
+		})
+	}
+	fake.getParametersWithContextReturnsOnCall[i] = struct {
+		result1 *ssm.GetParametersOutput
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeSSMClient) PutParameterWithContext(arg1 aws.Context, arg2 *ssm.PutParameterInput, arg3 ...request.Option) (*ssm.PutParameterOutput, error) {
+	fake.putParameterWithContextMutex.Lock()
+	ret, specificReturn := fake.putParameterWithContextReturnsOnCall[len(fake.putParameterWithContextArgsForCall)]
+	fake.putParameterWithContextArgsForCall = append(fake.putParameterWithContextArgsForCall, struct {
+		arg1 aws.Context
+		arg2 *ssm.PutParameterInput
+		arg3 []request.Option
+	}{arg1, arg2, arg3})
+	stub := fake.PutParameterWithContextStub
+	fakeReturns := fake.putParameterWithContextReturns
+	fake.recordInvocation("PutParameterWithContext", []interface{}{arg1, arg2, arg3})
+	fake.putParameterWithContextMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3...)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeSSMClient) PutParameterWithContextCallCount() int {
+	fake.putParameterWithContextMutex.RLock()
+	defer fake.putParameterWithContextMutex.RUnlock()
+	return len(fake.putParameterWithContextArgsForCall)
+}
+
+func (fake *FakeSSMClient) PutParameterWithContextCalls(stub func(aws.Context, *ssm.PutParameterInput, ...request.Option) (*ssm.PutParameterOutput, error)) {
+	fake.putParameterWithContextMutex.Lock()
+	defer fake.putParameterWithContextMutex.Unlock()
+	fake.PutParameterWithContextStub = stub
+}
+
+func (fake *FakeSSMClient) PutParameterWithContextArgsForCall(i int) (aws.Context, *ssm.PutParameterInput, []request.Option) {
+	fake.putParameterWithContextMutex.RLock()
+	defer fake.putParameterWithContextMutex.RUnlock()
+	argsForCall := fake.putParameterWithContextArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeSSMClient) PutParameterWithContextReturns(result1 *ssm.PutParameterOutput, result2 error) {
+	fake.putParameterWithContextMutex.Lock()
+	defer fake.putParameterWithContextMutex.Unlock()
+	fake.PutParameterWithContextStub = nil
+	fake.putParameterWithContextReturns = struct {
+		result1 *ssm.PutParameterOutput
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeSSMClient) PutParameterWithContextReturnsOnCall(i int, result1 *ssm.PutParameterOutput, result2 error) {
+	fake.putParameterWithContextMutex.Lock()
+	defer fake.putParameterWithContextMutex.Unlock()
+	fake.PutParameterWithContextStub = nil
+	if fake.putParameterWithContextReturnsOnCall == nil {
+		fake.putParameterWithContextReturnsOnCall = make(map[int]struct {
+			result1 *ssm.PutParameterOutput
+			result2 error
+		})
+	}
+	fake.putParameterWithContextReturnsOnCall[i] = struct {
+		result1 *ssm.PutParameterOutput
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeSSMClient) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
-	fake.getParameterMutex.RLock()
-	defer fake.getParameterMutex.RUnlock()
+	fake.describeParametersWithContextMutex.RLock()
+	defer fake.describeParametersWithContextMutex.RUnlock()
+	fake.getParameterWithContextMutex.RLock()
+	defer fake.getParameterWithContextMutex.RUnlock()
+	fake.getParametersByPathWithContextMutex.RLock()
+	defer fake.getParametersByPathWithContextMutex.RUnlock()
+	fake.getParametersWithContextMutex.RLock()
+	defer fake.getParametersWithContextMutex.RUnlock()
+	fake.putParameterWithContextMutex.RLock()
+	defer fake.putParameterWithContextMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
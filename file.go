@@ -0,0 +1,78 @@
+package environment
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// fileWriter writes secrets to files that are only readable by the current user,
+// and keeps track of the files so that they can be removed.
+type fileWriter struct {
+	dir string
+
+	mu    sync.Mutex
+	paths []string
+}
+
+// dryRunKey is set on the context when checking references (see Check),
+// in which case no files are written.
+type dryRunKey struct{}
+
+// write data to a new file and return the path of the file.
+func (w *fileWriter) write(ctx context.Context, data []byte) (string, error) {
+	if dryRun, _ := ctx.Value(dryRunKey{}).(bool); dryRun {
+		return "", nil
+	}
+	path, err := writeFile(w.dir, data)
+	if err != nil {
+		return "", err
+	}
+	w.mu.Lock()
+	w.paths = append(w.paths, path)
+	w.mu.Unlock()
+	return path, nil
+}
+
+// removeAll removes the files that have been written, and returns the first error (if any).
+func (w *fileWriter) removeAll() error {
+	w.mu.Lock()
+	paths := w.paths
+	w.paths = nil
+	w.mu.Unlock()
+
+	var err error
+	for _, path := range paths {
+		if e := os.Remove(path); e != nil && !os.IsNotExist(e) && err == nil {
+			err = fmt.Errorf("failed to remove file: %s", e)
+		}
+	}
+	return err
+}
+
+// writeFile writes data to a new file in dir that is only readable by the
+// current user, and returns the path of the file.
+func writeFile(dir string, data []byte) (string, error) {
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	f, err := os.CreateTemp(dir, "aws-env-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create file: %s", err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", fmt.Errorf("failed to write file: %s", err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", fmt.Errorf("failed to close file: %s", err)
+	}
+	if err := os.Chmod(f.Name(), 0400); err != nil {
+		os.Remove(f.Name())
+		return "", fmt.Errorf("failed to set file permissions: %s", err)
+	}
+	return f.Name(), nil
+}
@@ -0,0 +1,117 @@
+package environment
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	interpolationStart  = "${"
+	interpolationEnd    = '}'
+	interpolationEscape = "$" + interpolationStart
+)
+
+// interpolation of references that are embedded in a larger value, e.g.
+// postgres://app:${sm://db#password}@host/db.
+type interpolation struct {
+	index int
+	name  string
+
+	// literals surround the references, i.e. there is always one
+	// more literal than there are references.
+	literals []string
+	refs     []*reference
+
+	// file is true if the interpolated value should be written to a
+	// file, see WithFileSuffix.
+	file bool
+}
+
+// value returns the interpolated value once the references have been resolved.
+func (in *interpolation) value() string {
+	var b strings.Builder
+	for i, ref := range in.refs {
+		b.WriteString(in.literals[i])
+		b.WriteString(ref.secret)
+	}
+	b.WriteString(in.literals[len(in.refs)])
+	return b.String()
+}
+
+// parseInterpolation returns nil if the value does not embed a reference using one of
+// the registered schemes. Escaped references ("$${") are replaced with a literal "${",
+// and "${...}" that does not contain a reference (e.g. "${HOME}") is left as is.
+func (m *Manager) parseInterpolation(name, value string) *interpolation {
+	if !m.interpolates(value) {
+		return nil
+	}
+
+	in := &interpolation{name: name, file: m.writesFile(name)}
+	var literal strings.Builder
+	for {
+		i := strings.Index(value, interpolationStart)
+		if i < 0 {
+			literal.WriteString(value)
+			break
+		}
+		if i > 0 && value[i-1] == interpolationEscape[0] {
+			literal.WriteString(value[:i-1] + interpolationStart)
+			value = value[i+len(interpolationStart):]
+			continue
+		}
+		literal.WriteString(value[:i])
+		value = value[i+len(interpolationStart):]
+
+		end := closingBrace(value)
+		if end < 0 {
+			end = len(value)
+		}
+		ref := m.parseReference(name, value[:end], true)
+		switch {
+		case ref == nil:
+			literal.WriteString(interpolationStart)
+			continue
+		case ref.err != nil:
+		case end == len(value):
+			ref.err = fmt.Errorf("%w: missing closing %q", ErrInvalidReference, interpolationEnd)
+		case ref.expands():
+			ref.err = fmt.Errorf("%w: references that expand into several variables cannot be embedded in a value", ErrInvalidReference)
+		}
+		in.refs = append(in.refs, ref)
+		in.literals = append(in.literals, literal.String())
+		literal.Reset()
+		if end < len(value) {
+			end++
+		}
+		value = value[end:]
+	}
+	in.literals = append(in.literals, literal.String())
+	return in
+}
+
+// interpolates returns true if the value embeds a reference using one of the registered schemes.
+func (m *Manager) interpolates(value string) bool {
+	for scheme := range m.resolvers {
+		if strings.Contains(value, interpolationStart+scheme+schemeDelimiter) {
+			return true
+		}
+	}
+	return false
+}
+
+// closingBrace returns the index of the brace that closes an embedded reference, taking
+// any nested braces (e.g. in a JMESPath expression) into account, or -1 if there is none.
+func closingBrace(s string) int {
+	depth := 1
+	for i, r := range s {
+		switch r {
+		case '{':
+			depth++
+		case interpolationEnd:
+			if depth--; depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
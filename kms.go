@@ -0,0 +1,110 @@
+package environment
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/kms"
+)
+
+const (
+	kmsScheme = "kms"
+
+	// kmsContextPrefix is the prefix for query parameters that make up the encryption
+	// context, e.g. kms://<ciphertext>?context.service=api.
+	kmsContextPrefix = "context."
+)
+
+// kmsPath is a parsed KMS path, e.g. <base64 encoded ciphertext>?keyId=alias/app&context.service=api.
+type kmsPath struct {
+	ciphertext        []byte
+	keyID             string
+	encryptionContext map[string]*string
+}
+
+func parseKMSPath(path string) (*kmsPath, error) {
+	cipher, query, _ := strings.Cut(path, "?")
+	data, err := base64.StdEncoding.DecodeString(cipher)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to decode base64 cipher: %s", ErrInvalidReference, err)
+	}
+	params, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to parse query: %s", ErrInvalidReference, err)
+	}
+
+	p := &kmsPath{ciphertext: data}
+	for key := range params {
+		switch {
+		case key == "keyId":
+			p.keyID = params.Get(key)
+		case strings.HasPrefix(key, kmsContextPrefix) && len(key) > len(kmsContextPrefix):
+			if p.encryptionContext == nil {
+				p.encryptionContext = make(map[string]*string)
+			}
+			p.encryptionContext[strings.TrimPrefix(key, kmsContextPrefix)] = aws.String(params.Get(key))
+		default:
+			return nil, fmt.Errorf("%w: unknown query parameter: %q", ErrInvalidReference, key)
+		}
+	}
+	return p, nil
+}
+
+// Encrypt the plaintext using the given KMS key (a key ID, key ARN, alias name or alias ARN) and
+// optional encryption context, and return a kms:// reference that can be used to decrypt it.
+func (m *Manager) Encrypt(ctx context.Context, keyID string, plaintext []byte, encryptionContext map[string]string) (string, error) {
+	ctx, cancel := requestContext(ctx, m.requestTimeout)
+	defer cancel()
+
+	in := &kms.EncryptInput{KeyId: aws.String(keyID), Plaintext: plaintext}
+	if len(encryptionContext) > 0 {
+		in.EncryptionContext = aws.StringMap(encryptionContext)
+	}
+	res, err := m.kms.EncryptWithContext(ctx, in)
+	if err != nil {
+		return "", classify(err)
+	}
+
+	ref := kmsScheme + schemeDelimiter + base64.StdEncoding.EncodeToString(res.CiphertextBlob)
+	if len(encryptionContext) > 0 {
+		params := make(url.Values, len(encryptionContext))
+		for k, v := range encryptionContext {
+			params.Set(kmsContextPrefix+k, v)
+		}
+		ref += "?" + params.Encode()
+	}
+	return ref, nil
+}
+
+// kmsResolver decrypts KMS references (kms://<base64 encoded ciphertext>).
+type kmsResolver struct {
+	client  KMSClient
+	timeout time.Duration
+}
+
+func (r *kmsResolver) Resolve(ctx context.Context, path string) (string, error) {
+	p, err := parseKMSPath(path)
+	if err != nil {
+		return "", err
+	}
+	ctx, cancel := requestContext(ctx, r.timeout)
+	defer cancel()
+
+	in := &kms.DecryptInput{
+		CiphertextBlob:    p.ciphertext,
+		EncryptionContext: p.encryptionContext,
+	}
+	if p.keyID != "" {
+		in.KeyId = aws.String(p.keyID)
+	}
+	res, err := r.client.DecryptWithContext(ctx, in)
+	if err != nil {
+		return "", err
+	}
+	return string(res.Plaintext), nil
+}
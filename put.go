@@ -0,0 +1,223 @@
+package environment
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+// Put writes the value to the secret (sm://) or parameter (ssm://) referenced by ref, using the same syntax
+// as when reading. Parameters are written as SecureString, and secrets are created if they do not exist. The
+// KMS key (if any) is used to encrypt parameters and new secrets, and existing parameters otherwise keep the
+// key they are encrypted with. If ref has a #<key>, only that (top-level) key of the JSON document is set and
+// other keys are preserved, which is not atomic for concurrent writes.
+func (m *Manager) Put(ctx context.Context, ref, value, kmsKeyID string) error {
+	r := m.parseReference("", ref, false)
+	if r == nil {
+		return fmt.Errorf("%w: not a reference: %q", ErrInvalidReference, ref)
+	}
+	switch {
+	case r.err != nil:
+		return r.err
+	case r.expands(), r.optional, r.file, len(r.transforms) > 0, strings.Contains(r.path, "?"):
+		return fmt.Errorf("%w: options are not supported when writing: %q", ErrInvalidReference, ref)
+	}
+
+	switch r.scheme {
+	case smScheme:
+		return m.putSecret(ctx, r, value, kmsKeyID)
+	case ssmScheme:
+		return m.putParameter(ctx, r, value, kmsKeyID)
+	}
+	return fmt.Errorf("%w: writing is only supported for %s:// and %s:// references", ErrInvalidReference, smScheme, ssmScheme)
+}
+
+func (m *Manager) putParameter(ctx context.Context, r *reference, value, kmsKeyID string) error {
+	p, err := parseSSMPath(r.path)
+	if err != nil {
+		return err
+	}
+	if p.selector != "" {
+		return fmt.Errorf("%w: versions and labels are not supported when writing", ErrInvalidReference)
+	}
+
+	if r.multiValue {
+		current, err := m.getParameter(ctx, p.name)
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return fmt.Errorf("failed to get parameter: %w", err)
+		}
+		if value, err = setKey(current, r.key, value); err != nil {
+			return err
+		}
+	}
+
+	// Overwriting a parameter without a key ID would encrypt it using the AWS managed
+	// key, so the key of an existing parameter is used unless another key is given.
+	if kmsKeyID == "" {
+		if kmsKeyID, err = m.parameterKeyID(ctx, p.name); err != nil {
+			return fmt.Errorf("failed to describe parameter: %w", err)
+		}
+	}
+
+	input := &ssm.PutParameterInput{
+		Name:      aws.String(p.name),
+		Value:     aws.String(value),
+		Type:      aws.String(ssm.ParameterTypeSecureString),
+		Overwrite: aws.Bool(true),
+	}
+	if kmsKeyID != "" {
+		input.KeyId = aws.String(kmsKeyID)
+	}
+
+	ctx, cancel := requestContext(ctx, m.requestTimeout)
+	defer cancel()
+	if _, err := m.ssm.PutParameterWithContext(ctx, input); err != nil {
+		return fmt.Errorf("failed to put parameter: %w", classify(err))
+	}
+	return nil
+}
+
+// getParameter returns the decrypted value of the parameter, or an empty string
+// and ErrNotFound if it does not exist.
+func (m *Manager) getParameter(ctx context.Context, name string) (string, error) {
+	ctx, cancel := requestContext(ctx, m.requestTimeout)
+	defer cancel()
+
+	res, err := m.ssm.GetParameterWithContext(ctx, &ssm.GetParameterInput{
+		Name:           aws.String(name),
+		WithDecryption: aws.Bool(true),
+	})
+	if err != nil {
+		return "", classify(err)
+	}
+	return aws.StringValue(res.Parameter.Value), nil
+}
+
+// parameterKeyID returns the ID of the KMS key used to encrypt the parameter, or
+// an empty string if the parameter does not exist (or is not a SecureString).
+func (m *Manager) parameterKeyID(ctx context.Context, name string) (string, error) {
+	ctx, cancel := requestContext(ctx, m.requestTimeout)
+	defer cancel()
+
+	res, err := m.ssm.DescribeParametersWithContext(ctx, &ssm.DescribeParametersInput{
+		ParameterFilters: []*ssm.ParameterStringFilter{{
+			Key:    aws.String("Name"),
+			Option: aws.String("Equals"),
+			Values: aws.StringSlice([]string{name}),
+		}},
+	})
+	if err != nil {
+		return "", classify(err)
+	}
+	for _, p := range res.Parameters {
+		if aws.StringValue(p.Name) == name {
+			return aws.StringValue(p.KeyId), nil
+		}
+	}
+	return "", nil
+}
+
+func (m *Manager) putSecret(ctx context.Context, r *reference, value, kmsKeyID string) error {
+	p, err := parseSMPath(r.path)
+	if err != nil {
+		return err
+	}
+
+	if r.multiValue {
+		current, err := m.getSecretString(ctx, p.id)
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return fmt.Errorf("failed to get secret: %w", err)
+		}
+		if value, err = setKey(current, r.key, value); err != nil {
+			return err
+		}
+	}
+
+	putCtx, cancel := requestContext(ctx, m.requestTimeout)
+	defer cancel()
+	_, err = m.sm.PutSecretValueWithContext(putCtx, &secretsmanager.PutSecretValueInput{
+		SecretId:     aws.String(p.id),
+		SecretString: aws.String(value),
+	})
+	switch err = classify(err); {
+	case err == nil:
+		return nil
+	case !errors.Is(err, ErrNotFound):
+		return fmt.Errorf("failed to put secret value: %w", err)
+	}
+
+	// The secret does not exist, so it is created instead.
+	input := &secretsmanager.CreateSecretInput{
+		Name:         aws.String(p.id),
+		SecretString: aws.String(value),
+	}
+	if kmsKeyID != "" {
+		input.KmsKeyId = aws.String(kmsKeyID)
+	}
+
+	createCtx, cancel := requestContext(ctx, m.requestTimeout)
+	defer cancel()
+	if _, err := m.sm.CreateSecretWithContext(createCtx, input); err != nil {
+		return fmt.Errorf("failed to create secret: %w", classify(err))
+	}
+	return nil
+}
+
+// getSecretString returns the current value of the secret, or an empty string
+// and ErrNotFound if it does not exist.
+func (m *Manager) getSecretString(ctx context.Context, id string) (string, error) {
+	ctx, cancel := requestContext(ctx, m.requestTimeout)
+	defer cancel()
+
+	res, err := m.sm.GetSecretValueWithContext(ctx, &secretsmanager.GetSecretValueInput{SecretId: aws.String(id)})
+	if err != nil {
+		return "", classify(err)
+	}
+	if res.SecretString == nil {
+		return "", fmt.Errorf("%w: binary secrets cannot be updated by key", ErrInvalidReference)
+	}
+	return aws.StringValue(res.SecretString), nil
+}
+
+// setKey sets the top-level key of a JSON document to the value, and returns the updated document. An
+// empty document is treated as an empty object, so that multi-value secrets can be created by key.
+func setKey(document, key, value string) (string, error) {
+	o := make(map[string]json.RawMessage)
+	if document != "" {
+		var err error
+		if o, err = unmarshalMultiValue(document); err != nil {
+			return "", err
+		}
+	}
+
+	v, err := marshalJSON(value)
+	if err != nil {
+		return "", err
+	}
+	o[key] = v
+
+	b, err := marshalJSON(o)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// marshalJSON is the same as json.Marshal, except that it does not escape HTML
+// (e.g. "&" in connection strings) since the result is not used in HTML.
+func marshalJSON(v interface{}) ([]byte, error) {
+	var b bytes.Buffer
+	e := json.NewEncoder(&b)
+	e.SetEscapeHTML(false)
+	if err := e.Encode(v); err != nil {
+		return nil, fmt.Errorf("failed to marshal json: %s", err)
+	}
+	return bytes.TrimSuffix(b.Bytes(), []byte("\n")), nil
+}
@@ -0,0 +1,243 @@
+package environment
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/jmespath/go-jmespath"
+)
+
+// reference to a secret value from the environment.
+type reference struct {
+	index      int
+	name       string
+	scheme     string
+	path       string
+	key        string
+	multiValue bool
+
+	// query is set if the key of a multi-value reference is a valid JMESPath
+	// expression, and is used if the key is not a top-level key of the secret.
+	query *jmespath.JMESPath
+
+	// expand is true for references to a path (ending with "/") that
+	// expand into several environment variables, e.g. ssm:///app/prod/.
+	expand bool
+
+	// embedded is true for references that are interpolated into a
+	// larger value, see parseInterpolation.
+	embedded bool
+
+	// expandJSON is true for references to all keys of a multi-value
+	// secret (using "#*"), which expand into one variable per key.
+	expandJSON bool
+
+	expandOptions expandOptions
+
+	// transforms that are applied to the secret (or each of the secrets)
+	// once the reference has been resolved, e.g. "|base64d|trim".
+	transforms []string
+
+	// optional references resolve to their default value (or an empty
+	// string) if the secret or multi-value key does not exist.
+	optional     bool
+	defaultValue string
+
+	// file is true if the secret should be written to a file, in which
+	// case the environment variable is set to the path of the file.
+	file bool
+
+	// err is set if the reference could not be parsed, and failed is
+	// set if it could not be parsed or resolved.
+	err    error
+	failed *ReferenceError
+
+	// secret (or secrets, for references that expand) is set when the
+	// reference has been resolved.
+	secret  string
+	secrets map[string]string
+}
+
+// expands returns true if the reference expands into several environment variables.
+func (r *reference) expands() bool {
+	return r.expand || r.expandJSON
+}
+
+// lookup returns the lookup for the reference.
+func (r *reference) lookup() lookup {
+	return lookup{scheme: r.scheme, path: r.path, expand: r.expand}
+}
+
+// expandOptions control how the environment variables are named when a reference
+// expands into several variables. By default the path of the reference is stripped
+// from the names, which are then upper-cased and have any characters that are not
+// letters, digits or underscores replaced with underscores (e.g. /app/prod/db-password
+// becomes DB_PASSWORD for ssm:///app/prod/). For multi-value secrets, keys can be
+// used to only expand the listed keys.
+type expandOptions struct {
+	prefix string
+	strip  bool
+	upper  bool
+	keys   []string
+}
+
+// envName returns the name of the environment variable for a secret with the
+// given name that was found below path.
+func (o expandOptions) envName(path, name string) string {
+	if o.strip {
+		name = strings.TrimPrefix(name, path)
+	}
+	name = strings.Map(func(r rune) rune {
+		if r == '_' || r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return r
+		}
+		return '_'
+	}, strings.TrimLeft(name, "/"))
+	if o.upper {
+		name = strings.ToUpper(name)
+	}
+	return o.prefix + name
+}
+
+// parseReference returns nil if the value does not reference a secret using one of the registered
+// schemes. Query parameters that are handled by the Manager (rather than the resolver) are removed
+// from the path of the reference, and errors are stored on the reference so that they can be
+// reported in the same order as errors that occur when resolving. Embedded references are part
+// of a larger value, see parseInterpolation.
+func (m *Manager) parseReference(name, value string, embedded bool) *reference {
+	scheme, rest, ok := strings.Cut(value, schemeDelimiter)
+	if !ok {
+		return nil
+	}
+	resolver, ok := m.resolvers[scheme]
+	if !ok {
+		return nil
+	}
+
+	rest, transforms := cutTransforms(rest)
+
+	// # is not a legal character in secrets manager, parameter store or an
+	// encrypted (and base64 encoded) string from KMS. I.e. it should only
+	// be present if we are dealing with a multi-value secret.
+	path, key, isMultiValueSecret := strings.Cut(rest, mvsDelimiter)
+
+	ref := &reference{
+		name:          name,
+		scheme:        scheme,
+		path:          path,
+		key:           key,
+		multiValue:    isMultiValueSecret,
+		transforms:    transforms,
+		embedded:      embedded,
+		file:          !embedded && m.writesFile(name),
+		expandOptions: expandOptions{strip: true, upper: true},
+	}
+
+	path, query, _ := strings.Cut(path, "?")
+	if _, ok := resolver.(Expander); ok && strings.HasSuffix(path, "/") {
+		ref.expand = true
+	}
+	if isMultiValueSecret && key == "*" {
+		ref.expandJSON = true
+	}
+	if isMultiValueSecret && !ref.expandJSON {
+		// Keys that are not valid expressions (e.g. "api-key") can still
+		// be used to reference a top-level key.
+		ref.query, _ = jmespath.Compile(key)
+	}
+
+	params, err := url.ParseQuery(query)
+	if err != nil {
+		ref.err = fmt.Errorf("%w: failed to parse query: %s", ErrInvalidReference, err)
+		return ref
+	}
+	for _, k := range sortedKeys(params) {
+		ok, err := ref.setOption(k, params.Get(k))
+		if err != nil {
+			ref.err = err
+			return ref
+		}
+		if ok {
+			delete(params, k)
+		}
+	}
+	if len(params) > 0 {
+		path += "?" + params.Encode()
+	}
+	ref.path = path
+
+	if ref.expands() {
+		// Only set by WithFileSuffix, since ?file is not supported.
+		ref.file = false
+	}
+	if ref.expand && ref.multiValue {
+		ref.err = fmt.Errorf("%w: multi-value keys are not supported for references to a path", ErrInvalidReference)
+	}
+	return ref
+}
+
+// setOption sets a query parameter that is handled by the Manager, and returns
+// false if it is not, in which case it is passed on to the resolver.
+func (r *reference) setOption(key, value string) (bool, error) {
+	var (
+		supported bool
+		err       error
+	)
+	switch key {
+	case "prefix":
+		r.expandOptions.prefix, supported = value, r.expands()
+	case "upper":
+		r.expandOptions.upper, err = strconv.ParseBool(value)
+		supported = r.expands()
+	case "strip":
+		r.expandOptions.strip, err = strconv.ParseBool(value)
+		supported = r.expand
+	case "keys":
+		r.expandOptions.keys, supported = strings.Split(value, ","), r.expandJSON
+	case "default":
+		r.defaultValue, r.optional, supported = value, true, !r.expands()
+	case "optional":
+		r.optional, err = parseFlag(value)
+		supported = true
+	case "file":
+		r.file, err = parseFlag(value)
+		supported = !r.expands()
+	default:
+		return false, nil
+	}
+	if !supported {
+		return true, fmt.Errorf("%w: %q is not supported for this reference", ErrInvalidReference, key)
+	}
+	if err != nil {
+		return true, fmt.Errorf("%w: invalid value for %q: %q", ErrInvalidReference, key, value)
+	}
+	return true, nil
+}
+
+// writesFile returns true if secrets referenced by the environment variable
+// should be written to files, see WithFileSuffix.
+func (m *Manager) writesFile(name string) bool {
+	return m.fileSuffix != "" && strings.HasSuffix(name, m.fileSuffix)
+}
+
+// parseFlag parses the value of a boolean query parameter, where an
+// empty value (e.g. "?optional") is treated as true.
+func parseFlag(value string) (bool, error) {
+	if value == "" {
+		return true, nil
+	}
+	return strconv.ParseBool(value)
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,63 @@
+package environment
+
+import (
+	"context"
+	"time"
+)
+
+// Resolver for secrets referenced using a URI scheme, e.g. "sm" for "sm://<path>".
+type Resolver interface {
+	// Resolve the secret value for the given path, which is everything after "<scheme>://"
+	// and before the "#<key>" used for multi-value secrets.
+	Resolve(ctx context.Context, path string) (string, error)
+}
+
+// BatchResolver can be implemented by resolvers that support resolving more than one path per request.
+type BatchResolver interface {
+	Resolver
+
+	// BatchSize returns the maximum number of paths that will be passed to ResolveBatch.
+	BatchSize() int
+
+	// ResolveBatch resolves the secret values for the given paths. The returned
+	// values and errors must have the same length and order as the paths.
+	ResolveBatch(ctx context.Context, paths []string) ([]string, []error)
+}
+
+// batchFilter can be implemented by batch resolvers that cannot batch every path,
+// in which case the other paths are resolved one at a time using Resolve.
+type batchFilter interface {
+	batchable(path string) bool
+}
+
+// Expander can be implemented by resolvers that support references to a path (i.e. ending with "/")
+// which expand into one environment variable per secret below the path, e.g. ssm:///app/prod/.
+type Expander interface {
+	// Expand returns the secret values below the given path, keyed by their full name.
+	Expand(ctx context.Context, path string) (map[string]string, error)
+}
+
+// ResolverFunc is an adapter that allows the use of ordinary functions as resolvers.
+type ResolverFunc func(ctx context.Context, path string) (string, error)
+
+// Resolve calls f(ctx, path).
+func (f ResolverFunc) Resolve(ctx context.Context, path string) (string, error) {
+	return f(ctx, path)
+}
+
+// WithResolver registers a resolver for references with the given scheme (e.g. "vault"
+// for "vault://<path>"). Registering a resolver for "sm", "ssm" or "kms" replaces the
+// built-in resolver for that scheme.
+func WithResolver(scheme string, r Resolver) Option {
+	return func(m *Manager) {
+		m.resolvers[scheme] = r
+	}
+}
+
+// requestContext returns a context for a single request to AWS.
+func requestContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
+}
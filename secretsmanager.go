@@ -0,0 +1,255 @@
+package environment
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+)
+
+const (
+	smScheme = "sm"
+
+	// smBatchSize is the maximum number of secret IDs accepted by BatchGetSecretValue.
+	smBatchSize = 20
+)
+
+// Encodings for binary secrets, which can be selected using the "binary" query
+// parameter, e.g. sm://<path>?binary=base64.
+const (
+	binaryRaw    = "raw"
+	binaryBase64 = "base64"
+	binaryHex    = "hex"
+	binaryFile   = "file"
+)
+
+// smPath is a parsed secrets manager path, e.g. <secret-id>?versionStage=AWSPENDING&binary=base64.
+type smPath struct {
+	id           string
+	versionStage string
+	versionID    string
+	binary       string
+}
+
+// versioned returns true if the path selects a specific version of the secret.
+func (p *smPath) versioned() bool {
+	return p.versionStage != "" || p.versionID != ""
+}
+
+func parseSMPath(path string) (*smPath, error) {
+	id, query, _ := strings.Cut(path, "?")
+	params, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to parse query: %s", ErrInvalidReference, err)
+	}
+
+	p := &smPath{id: id, binary: binaryRaw}
+	for key := range params {
+		switch key {
+		case "binary":
+			p.binary = params.Get(key)
+		case "versionStage":
+			p.versionStage = params.Get(key)
+		case "versionId":
+			p.versionID = params.Get(key)
+		default:
+			return nil, fmt.Errorf("%w: unknown query parameter: %q", ErrInvalidReference, key)
+		}
+	}
+	switch p.binary {
+	case binaryRaw, binaryBase64, binaryHex, binaryFile:
+	default:
+		return nil, fmt.Errorf("%w: unknown binary encoding: %q", ErrInvalidReference, p.binary)
+	}
+	return p, nil
+}
+
+// smResolver resolves secrets manager references (sm://<path>).
+type smResolver struct {
+	client  SMClient
+	timeout time.Duration
+	files   *fileWriter
+
+	// batchDenied is set (atomically) to 1 if the caller is not allowed to
+	// use BatchGetSecretValue, in which case secrets are fetched individually.
+	batchDenied int32
+}
+
+func (r *smResolver) Resolve(ctx context.Context, path string) (string, error) {
+	p, err := parseSMPath(path)
+	if err != nil {
+		return "", err
+	}
+	return r.getSecretValue(ctx, p)
+}
+
+func (r *smResolver) BatchSize() int {
+	if atomic.LoadInt32(&r.batchDenied) == 1 {
+		return 1
+	}
+	return smBatchSize
+}
+
+// batchable returns false for references to a specific version, since
+// BatchGetSecretValue only returns the current version of each secret.
+func (r *smResolver) batchable(path string) bool {
+	p, err := parseSMPath(path)
+	return err == nil && !p.versioned()
+}
+
+// ResolveBatch fetches up to smBatchSize secrets using BatchGetSecretValue. Secrets
+// that are not present in the response (e.g. when referenced by a partial ARN) are
+// fetched individually (and concurrently), as are all secrets if the caller is not allowed
+// to use the batch API.
+// BatchGetSecretValue only returns the current version, so references to a specific
+// version (stage) are always fetched individually.
+func (r *smResolver) ResolveBatch(ctx context.Context, paths []string) ([]string, []error) {
+	var (
+		values = make([]string, len(paths))
+		errs   = make([]error, len(paths))
+		parsed = make([]*smPath, len(paths))
+		ids    []string
+		seen   = make(map[string]bool)
+	)
+	for i, path := range paths {
+		parsed[i], errs[i] = parseSMPath(path)
+		if errs[i] != nil || parsed[i].versioned() {
+			continue
+		}
+		if id := parsed[i].id; !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	var (
+		found  map[string]*secretsmanager.SecretValueEntry
+		failed map[string]error
+		err    error
+	)
+	if len(ids) > 1 && atomic.LoadInt32(&r.batchDenied) == 0 {
+		found, failed, err = r.batchGetSecretValues(ctx, ids)
+		if e, ok := err.(awserr.Error); ok && e.Code() == "AccessDeniedException" {
+			atomic.StoreInt32(&r.batchDenied, 1)
+			return r.ResolveBatch(ctx, paths)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i, p := range parsed {
+		if p == nil {
+			continue
+		}
+		if !p.versioned() {
+			if s, ok := found[p.id]; ok {
+				values[i], errs[i] = r.secretValue(ctx, p, s.SecretString, s.SecretBinary)
+				continue
+			}
+			if e, ok := failed[p.id]; ok {
+				errs[i] = e
+				continue
+			}
+			if err != nil {
+				errs[i] = err
+				continue
+			}
+		}
+
+		// Secrets that could not be fetched in the batch are fetched concurrently.
+		wg.Add(1)
+		go func(i int, p *smPath) {
+			defer wg.Done()
+			values[i], errs[i] = r.getSecretValue(ctx, p)
+		}(i, p)
+	}
+	wg.Wait()
+	return values, errs
+}
+
+// batchGetSecretValues fetches the secrets using BatchGetSecretValue, and returns
+// the secrets and errors from the response by the name and ARN of the secret.
+func (r *smResolver) batchGetSecretValues(ctx context.Context, ids []string) (map[string]*secretsmanager.SecretValueEntry, map[string]error, error) {
+	var (
+		found  = make(map[string]*secretsmanager.SecretValueEntry, 2*len(ids))
+		failed = make(map[string]error)
+		input  = &secretsmanager.BatchGetSecretValueInput{SecretIdList: aws.StringSlice(ids)}
+	)
+	for {
+		res, err := r.batchGetSecretValue(ctx, input)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// Secrets can be referenced by either name or ARN.
+		for _, s := range res.SecretValues {
+			found[aws.StringValue(s.Name)] = s
+			found[aws.StringValue(s.ARN)] = s
+		}
+		for _, e := range res.Errors {
+			failed[aws.StringValue(e.SecretId)] = awserr.New(aws.StringValue(e.ErrorCode), aws.StringValue(e.Message), nil)
+		}
+
+		if res.NextToken == nil {
+			return found, failed, nil
+		}
+		input.NextToken = res.NextToken
+	}
+}
+
+func (r *smResolver) batchGetSecretValue(ctx context.Context, input *secretsmanager.BatchGetSecretValueInput) (*secretsmanager.BatchGetSecretValueOutput, error) {
+	ctx, cancel := requestContext(ctx, r.timeout)
+	defer cancel()
+	return r.client.BatchGetSecretValueWithContext(ctx, input)
+}
+
+func (r *smResolver) getSecretValue(ctx context.Context, p *smPath) (string, error) {
+	ctx, cancel := requestContext(ctx, r.timeout)
+	defer cancel()
+
+	input := &secretsmanager.GetSecretValueInput{SecretId: aws.String(p.id)}
+	if p.versionStage != "" {
+		input.VersionStage = aws.String(p.versionStage)
+	}
+	if p.versionID != "" {
+		input.VersionId = aws.String(p.versionID)
+	}
+
+	res, err := r.client.GetSecretValueWithContext(ctx, input)
+	if err != nil {
+		return "", err
+	}
+	return r.secretValue(ctx, p, res.SecretString, res.SecretBinary)
+}
+
+// secretValue returns the secret string, or the binary secret using the encoding
+// selected for the path. The SDK has already base64 decoded the binary secret.
+func (r *smResolver) secretValue(ctx context.Context, p *smPath, secretString *string, secretBinary []byte) (string, error) {
+	if secretString != nil {
+		return aws.StringValue(secretString), nil
+	}
+
+	switch p.binary {
+	case binaryBase64:
+		return base64.StdEncoding.EncodeToString(secretBinary), nil
+	case binaryHex:
+		return hex.EncodeToString(secretBinary), nil
+	case binaryFile:
+		return r.files.write(ctx, secretBinary)
+	}
+
+	// Environment variables cannot contain null bytes.
+	if !utf8.Valid(secretBinary) || strings.ContainsRune(string(secretBinary), 0) {
+		return "", fmt.Errorf("%w: binary secret is not valid UTF-8 (use ?binary=base64, hex or file)", ErrInvalidReference)
+	}
+	return string(secretBinary), nil
+}
@@ -0,0 +1,283 @@
+package environment
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+const (
+	ssmScheme = "ssm"
+
+	// ssmBatchSize is the maximum number of names accepted by GetParameters.
+	ssmBatchSize = 10
+)
+
+// ssmLabel matches valid parameter labels, which can contain letters, numbers,
+// periods, hyphens and underscores, and cannot begin with a number.
+var ssmLabel = regexp.MustCompile(`^[a-zA-Z._-][a-zA-Z0-9._-]{0,99}$`)
+
+// ssmPath is a parsed parameter store path, which supports the native selector syntax for pinning a
+// version or label (<name>:<version> or <name>:<label>) or the equivalent query parameters
+// (<name>?version=<version> or <name>?label=<label>).
+type ssmPath struct {
+	name     string
+	selector string
+}
+
+// String returns the name (including selector) as accepted by GetParameters.
+func (p *ssmPath) String() string {
+	if p.selector == "" {
+		return p.name
+	}
+	return p.name + ":" + p.selector
+}
+
+func parseSSMPath(path string) (*ssmPath, error) {
+	path, query, _ := strings.Cut(path, "?")
+	params, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to parse query: %s", ErrInvalidReference, err)
+	}
+
+	// Parameter names cannot contain ":", so a colon after the start of the
+	// name (i.e. after "parameter/" for ARNs) is the start of a selector.
+	var start int
+	if i := strings.Index(path, ":parameter/"); strings.HasPrefix(path, "arn:") && i >= 0 {
+		start = i + len(":parameter/")
+	}
+	var (
+		p        = &ssmPath{name: path}
+		selected bool
+	)
+	if i := strings.LastIndex(path[start:], ":"); i >= 0 {
+		p.name, p.selector, selected = path[:start+i], path[start+i+1:], true
+	}
+
+	for key := range params {
+		switch key {
+		case "version", "label":
+			if selected || (params.Has("version") && params.Has("label")) {
+				return nil, fmt.Errorf("%w: only one version or label can be selected", ErrInvalidReference)
+			}
+			p.selector, selected = params.Get(key), true
+			if _, err := strconv.ParseUint(p.selector, 10, 64); key == "version" && err != nil {
+				return nil, fmt.Errorf("%w: invalid version: %q", ErrInvalidReference, p.selector)
+			}
+			if key == "label" && !ssmLabel.MatchString(p.selector) {
+				return nil, fmt.Errorf("%w: invalid label: %q", ErrInvalidReference, p.selector)
+			}
+		default:
+			return nil, fmt.Errorf("%w: unknown query parameter: %q", ErrInvalidReference, key)
+		}
+	}
+
+	// Empty selectors are rejected as well, since they would fetch the latest version.
+	if selected {
+		if _, err := strconv.ParseUint(p.selector, 10, 64); err != nil && !ssmLabel.MatchString(p.selector) {
+			return nil, fmt.Errorf("%w: invalid version or label: %q", ErrInvalidReference, p.selector)
+		}
+	}
+	return p, nil
+}
+
+// ssmResolver resolves parameter store references (ssm://<path>).
+type ssmResolver struct {
+	client  SSMClient
+	timeout time.Duration
+
+	// batchDenied is set (atomically) to 1 if the caller is not allowed to
+	// use GetParameters, in which case parameters are fetched individually.
+	batchDenied int32
+}
+
+func (r *ssmResolver) Resolve(ctx context.Context, path string) (string, error) {
+	values, errs := r.ResolveBatch(ctx, []string{path})
+	return values[0], errs[0]
+}
+
+// Expand fetches all parameters below the path using GetParametersByPath, including
+// parameters further down the hierarchy unless ?recursive=false is specified.
+func (r *ssmResolver) Expand(ctx context.Context, path string) (map[string]string, error) {
+	path, query, _ := strings.Cut(path, "?")
+	params, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to parse query: %s", ErrInvalidReference, err)
+	}
+
+	recursive := true
+	for key := range params {
+		switch key {
+		case "recursive":
+			if recursive, err = strconv.ParseBool(params.Get(key)); err != nil {
+				return nil, fmt.Errorf("%w: invalid value for %q: %q", ErrInvalidReference, key, params.Get(key))
+			}
+		default:
+			return nil, fmt.Errorf("%w: unknown query parameter: %q", ErrInvalidReference, key)
+		}
+	}
+	if path != "/" {
+		path = strings.TrimSuffix(path, "/")
+	}
+
+	var (
+		secrets = make(map[string]string)
+		input   = &ssm.GetParametersByPathInput{
+			Path:           aws.String(path),
+			Recursive:      aws.Bool(recursive),
+			WithDecryption: aws.Bool(true),
+		}
+	)
+	for {
+		res, err := r.getParametersByPath(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range res.Parameters {
+			secrets[aws.StringValue(p.Name)] = aws.StringValue(p.Value)
+		}
+		if res.NextToken == nil {
+			return secrets, nil
+		}
+		input.NextToken = res.NextToken
+	}
+}
+
+func (r *ssmResolver) getParametersByPath(ctx context.Context, input *ssm.GetParametersByPathInput) (*ssm.GetParametersByPathOutput, error) {
+	ctx, cancel := requestContext(ctx, r.timeout)
+	defer cancel()
+	return r.client.GetParametersByPathWithContext(ctx, input)
+}
+
+func (r *ssmResolver) BatchSize() int {
+	if atomic.LoadInt32(&r.batchDenied) == 1 {
+		return 1
+	}
+	return ssmBatchSize
+}
+
+// ResolveBatch fetches up to ssmBatchSize parameters in a single request. A single
+// parameter is fetched using GetParameter, and policies that only allow ssm:GetParameter
+// are supported by fetching the parameters individually (and concurrently) if the
+// caller is not allowed to use GetParameters.
+func (r *ssmResolver) ResolveBatch(ctx context.Context, paths []string) ([]string, []error) {
+	var (
+		values = make([]string, len(paths))
+		errs   = make([]error, len(paths))
+		parsed = make([]*ssmPath, len(paths))
+		names  []string
+		seen   = make(map[string]int)
+	)
+	for i, path := range paths {
+		parsed[i], errs[i] = parseSSMPath(path)
+		if errs[i] != nil {
+			continue
+		}
+		name := parsed[i].String()
+		if _, ok := seen[name]; !ok {
+			seen[name] = len(names)
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return values, errs
+	}
+	if len(names) == 1 || atomic.LoadInt32(&r.batchDenied) == 1 {
+		var (
+			wg      sync.WaitGroup
+			fetched = make([]string, len(names))
+			failed  = make([]error, len(names))
+		)
+		for i, name := range names {
+			wg.Add(1)
+			go func(i int, name string) {
+				defer wg.Done()
+				fetched[i], failed[i] = r.getParameter(ctx, name)
+			}(i, name)
+		}
+		wg.Wait()
+		for i, p := range parsed {
+			if p != nil {
+				j := seen[p.String()]
+				values[i], errs[i] = fetched[j], failed[j]
+			}
+		}
+		return values, errs
+	}
+
+	res, err := r.getParameters(ctx, names)
+	if e, ok := err.(awserr.Error); ok && e.Code() == "AccessDeniedException" {
+		atomic.StoreInt32(&r.batchDenied, 1)
+		return r.ResolveBatch(ctx, paths)
+	}
+	if err != nil {
+		for i, p := range parsed {
+			if p != nil {
+				errs[i] = err
+			}
+		}
+		return values, errs
+	}
+
+	// Parameters can be referenced by either name or ARN, with an optional selector.
+	found := make(map[string]string, 2*len(res.Parameters))
+	for _, p := range res.Parameters {
+		selector := strings.TrimPrefix(aws.StringValue(p.Selector), ":")
+		for _, name := range []string{aws.StringValue(p.Name), aws.StringValue(p.ARN)} {
+			found[(&ssmPath{name: name, selector: selector}).String()] = aws.StringValue(p.Value)
+		}
+	}
+	invalid := make(map[string]bool, len(res.InvalidParameters))
+	for _, name := range res.InvalidParameters {
+		invalid[aws.StringValue(name)] = true
+	}
+
+	for i, p := range parsed {
+		if p == nil {
+			continue
+		}
+		name := p.String()
+		if v, ok := found[name]; ok {
+			values[i] = v
+			continue
+		}
+		if invalid[name] {
+			errs[i] = fmt.Errorf("%w: parameter is invalid or does not exist: %q", ErrNotFound, name)
+			continue
+		}
+		errs[i] = fmt.Errorf("parameter missing from response: %q", name)
+	}
+	return values, errs
+}
+
+func (r *ssmResolver) getParameters(ctx context.Context, names []string) (*ssm.GetParametersOutput, error) {
+	ctx, cancel := requestContext(ctx, r.timeout)
+	defer cancel()
+	return r.client.GetParametersWithContext(ctx, &ssm.GetParametersInput{
+		Names:          aws.StringSlice(names),
+		WithDecryption: aws.Bool(true),
+	})
+}
+
+func (r *ssmResolver) getParameter(ctx context.Context, name string) (string, error) {
+	ctx, cancel := requestContext(ctx, r.timeout)
+	defer cancel()
+	res, err := r.client.GetParameterWithContext(ctx, &ssm.GetParameterInput{
+		Name:           aws.String(name),
+		WithDecryption: aws.Bool(true),
+	})
+	if err != nil {
+		return "", err
+	}
+	return aws.StringValue(res.Parameter.Value), nil
+}
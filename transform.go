@@ -0,0 +1,95 @@
+package environment
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+)
+
+const transformDelimiter = "|"
+
+// transform of a secret value, applied after it has been resolved (and extracted
+// from a multi-value secret).
+type transform func(string) (string, error)
+
+var transforms = map[string]transform{
+	"base64d": func(s string) (string, error) {
+		b, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return "", fmt.Errorf("invalid base64: %s", err)
+		}
+		return string(b), nil
+	},
+	"base64e": func(s string) (string, error) {
+		return base64.StdEncoding.EncodeToString([]byte(s)), nil
+	},
+	"trim": func(s string) (string, error) {
+		return strings.TrimSpace(s), nil
+	},
+	"json-escape": func(s string) (string, error) {
+		b, err := json.Marshal(s)
+		if err != nil {
+			return "", err
+		}
+		return string(b[1 : len(b)-1]), nil
+	},
+	"url-escape": func(s string) (string, error) {
+		// QueryEscape also escapes the characters that are reserved in the
+		// userinfo of a URL (e.g. ":" and "@"), but encodes spaces as "+".
+		return strings.ReplaceAll(url.QueryEscape(s), "+", "%20"), nil
+	},
+}
+
+// cutTransforms returns the value without any trailing transforms (e.g. "|base64d|trim"),
+// and the names of the transforms in the order they should be applied. Only names of known
+// transforms are cut, since "|" is also used for pipes in JMESPath expressions.
+func cutTransforms(value string) (string, []string) {
+	var names []string
+	for {
+		i := strings.LastIndex(value, transformDelimiter)
+		if i < 0 {
+			break
+		}
+		name := value[i+len(transformDelimiter):]
+		if _, ok := transforms[name]; !ok {
+			break
+		}
+		names = append([]string{name}, names...)
+		value = value[:i]
+	}
+	return value, names
+}
+
+// applyTransforms to the secret (or secrets) of a resolved reference. Unless the secret is written
+// to a file, the result must be valid in an environment variable (e.g. after base64d).
+func (r *reference) applyTransforms(toFile bool) error {
+	apply := func(s string) (string, error) {
+		for _, name := range r.transforms {
+			var err error
+			if s, err = transforms[name](s); err != nil {
+				return "", fmt.Errorf("%w: failed to apply transform %q: %s", ErrInvalidReference, name, err)
+			}
+		}
+		if len(r.transforms) > 0 && !toFile && (!utf8.ValidString(s) || strings.ContainsRune(s, 0)) {
+			return "", fmt.Errorf("%w: transformed value is not valid UTF-8 (use ?file)", ErrInvalidReference)
+		}
+		return s, nil
+	}
+
+	if !r.expands() {
+		var err error
+		r.secret, err = apply(r.secret)
+		return err
+	}
+	for _, name := range sortedKeys(r.secrets) {
+		v, err := apply(r.secrets[name])
+		if err != nil {
+			return err
+		}
+		r.secrets[name] = v
+	}
+	return nil
+}